@@ -0,0 +1,99 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command loadgen sends a known number of OTLP spans, metric series and log
+// records to a collector, tagged with the test run ID, and exits. The collector
+// runner deploys it next to the collector with --loadgen-image, and the smoke
+// tests check all of it arrived.
+//
+// It is configured with environment variables:
+//
+//   - OTLP_ENDPOINT: host:port of the collector's plaintext OTLP gRPC receiver
+//   - TEST_RUN_ID: value of the otelcol_google_e2e_loadgen attribute
+//   - LOADGEN_SPANS, LOADGEN_METRIC_SERIES, LOADGEN_LOGS: how many to send,
+//     default 0
+//   - LOADGEN_BATCH_SIZE: spans or log records per request, default 100
+//
+// Build the image from the repo root:
+//
+//	docker build . -f cmd/loadgen/Dockerfile -t loadgen:local
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/loadgen"
+)
+
+// Exports wait for the collector to start listening until then
+const timeout = 5 * time.Minute
+
+func main() {
+	logger := log.New(os.Stdout, "loadgen: ", log.LstdFlags|log.Lshortfile)
+
+	endpoint := os.Getenv("OTLP_ENDPOINT")
+	if endpoint == "" {
+		logger.Fatal("OTLP_ENDPOINT must be set")
+	}
+	cfg := loadgen.Config{
+		TestRunID:    os.Getenv("TEST_RUN_ID"),
+		Spans:        intFromEnv(logger, "LOADGEN_SPANS", 0),
+		MetricSeries: intFromEnv(logger, "LOADGEN_METRIC_SERIES", 0),
+		Logs:         intFromEnv(logger, "LOADGEN_LOGS", 0),
+		BatchSize:    intFromEnv(logger, "LOADGEN_BATCH_SIZE", 100),
+	}
+	if cfg.TestRunID == "" {
+		logger.Fatal("TEST_RUN_ID must be set")
+	}
+
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	if err := loadgen.Run(ctx, conn, cfg); err != nil {
+		logger.Fatal(err)
+	}
+	logger.Printf(
+		"Sent %v spans, %v metric series and %v log records to %v in %v",
+		cfg.Spans,
+		cfg.MetricSeries,
+		cfg.Logs,
+		endpoint,
+		time.Since(start).Round(time.Millisecond),
+	)
+}
+
+func intFromEnv(logger *log.Logger, name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		logger.Fatalf("%v must be a non-negative integer, got %q", name, value)
+	}
+	return n
+}
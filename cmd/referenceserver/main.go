@@ -0,0 +1,80 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command referenceserver is a reference implementation of the instrumented
+// test server, written with the OpenTelemetry Go SDK. It serves as an
+// executable spec of the scenario protocol for test server authors in the
+// language repos, and lets the runner be tested against a known good server.
+//
+// It is configured with the same environment variables the runner passes to
+// every test server:
+//
+//   - PROJECT_ID: the GCP project to export to
+//   - SUBSCRIPTION_MODE: pull, push or http
+//   - REQUEST_SUBSCRIPTION_NAME: subscription to pull requests from (pull)
+//   - RESPONSE_TOPIC_NAME: topic to publish responses to (pull and push)
+//   - PORT or PUSH_PORT: port to listen on (push and http)
+//   - CLOUD_TRACE_ENDPOINT: optional plaintext Cloud Trace endpoint override
+//
+// Build the image from the repo root:
+//
+//	docker build . -f cmd/referenceserver/Dockerfile -t referenceserver:local
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
+)
+
+func main() {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "referenceserver: ", log.LstdFlags|log.Lshortfile)
+
+	projectID := os.Getenv("PROJECT_ID")
+	if projectID == "" {
+		logger.Fatal("PROJECT_ID must be set")
+	}
+
+	s, err := newServerFromEnv(ctx, projectID)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer s.shutdown(ctx)
+
+	switch mode := setuptf.SubscriptionMode(os.Getenv("SUBSCRIPTION_MODE")); mode {
+	case setuptf.Pull:
+		err = runPull(ctx, s, projectID, os.Getenv("REQUEST_SUBSCRIPTION_NAME"), os.Getenv("RESPONSE_TOPIC_NAME"), logger)
+	case setuptf.Push:
+		err = runPush(ctx, s, projectID, listenPort(), os.Getenv("RESPONSE_TOPIC_NAME"), logger)
+	case setuptf.HTTP:
+		err = runHTTP(s, listenPort(), logger)
+	default:
+		logger.Fatalf("unknown SUBSCRIPTION_MODE %q", mode)
+	}
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
+
+func listenPort() string {
+	for _, env := range []string{"PUSH_PORT", "PORT"} {
+		if port := os.Getenv(env); port != "" {
+			return port
+		}
+	}
+	return "8080"
+}
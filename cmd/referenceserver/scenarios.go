@@ -0,0 +1,257 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/logging"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
+	gcppropagator "github.com/GoogleCloudPlatform/opentelemetry-operations-go/propagator"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/code"
+)
+
+const (
+	instrumentationName = "github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/cmd/referenceserver"
+	traceIDKey          = "trace_id"
+	spanIDKey           = "span_id"
+)
+
+// basicTrace creates a single span named basicTrace.
+func (s *server) basicTrace(ctx context.Context, req request) *response {
+	tracer := s.tracerProvider.Tracer(instrumentationName)
+	_, span := tracer.Start(ctx, "basicTrace", trace.WithAttributes(testIDAttr(req)))
+	span.End()
+	return traceResponse(span)
+}
+
+// complexTrace creates a trace with the structure
+//
+//	complexTrace/root
+//	├── complexTrace/child1 (SERVER)
+//	│   └── complexTrace/child2 (CLIENT)
+//	└── complexTrace/child3
+func (s *server) complexTrace(ctx context.Context, req request) *response {
+	tracer := s.tracerProvider.Tracer(instrumentationName)
+	attrs := trace.WithAttributes(testIDAttr(req))
+
+	rootCtx, root := tracer.Start(ctx, "complexTrace/root", attrs)
+	child1Ctx, child1 := tracer.Start(rootCtx, "complexTrace/child1", attrs, trace.WithSpanKind(trace.SpanKindServer))
+	_, child2 := tracer.Start(child1Ctx, "complexTrace/child2", attrs, trace.WithSpanKind(trace.SpanKindClient))
+	child2.End()
+	child1.End()
+	_, child3 := tracer.Start(rootCtx, "complexTrace/child3", attrs)
+	child3.End()
+	root.End()
+	return traceResponse(root)
+}
+
+// richTrace creates a trace with the structure
+//
+//	richTrace/root
+//	├── richTrace/linked
+//	└── richTrace/child (ERROR)
+//
+// richTrace/child links to richTrace/linked, has an event named
+// richTrace/event with the attribute event_key=event_value and an attribute
+// richTrace/long whose value is 300 bytes long, over the Cloud Trace limit.
+func (s *server) richTrace(ctx context.Context, req request) *response {
+	tracer := s.tracerProvider.Tracer(instrumentationName)
+	attrs := trace.WithAttributes(testIDAttr(req))
+
+	rootCtx, root := tracer.Start(ctx, "richTrace/root", attrs)
+	_, linked := tracer.Start(rootCtx, "richTrace/linked", attrs)
+	linked.End()
+	_, child := tracer.Start(
+		rootCtx,
+		"richTrace/child",
+		attrs,
+		trace.WithAttributes(attribute.String("richTrace/long", strings.Repeat("a", 300))),
+		trace.WithLinks(trace.Link{SpanContext: linked.SpanContext()}),
+	)
+	child.AddEvent("richTrace/event", trace.WithAttributes(attribute.String("event_key", "event_value")))
+	child.SetStatus(codes.Error, "richTrace error")
+	child.End()
+	root.End()
+	return traceResponse(root)
+}
+
+// basicPropagator creates a span named basicPropagator as a child of the span
+// context in the X-Cloud-Trace-Context request attribute.
+func (s *server) basicPropagator(ctx context.Context, req request) *response {
+	ctx = gcppropagator.CloudTraceFormatPropagator{}.Extract(ctx, propagation.HeaderCarrier(req.headers))
+	tracer := s.tracerProvider.Tracer(instrumentationName)
+	_, span := tracer.Start(ctx, "basicPropagator", trace.WithAttributes(testIDAttr(req)))
+	span.End()
+	return traceResponse(span)
+}
+
+// w3cPropagator creates a span named w3cPropagator as a child of the span
+// context in the traceparent and tracestate request attributes. It responds
+// with the traceparent and tracestate it would propagate downstream.
+func (s *server) w3cPropagator(ctx context.Context, req request) *response {
+	return s.propagate(ctx, req, "w3cPropagator", propagation.TraceContext{})
+}
+
+// baggagePropagator is like w3cPropagator, but also extracts the baggage
+// request attribute. Each baggage member is added to the span named
+// baggagePropagator as an attribute, and the baggage is propagated downstream.
+func (s *server) baggagePropagator(ctx context.Context, req request) *response {
+	return s.propagate(ctx, req, "baggagePropagator", propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// cloudTraceOneWayPropagator creates a span named cloudTraceOneWayPropagator
+// as a child of the span context in the X-Cloud-Trace-Context request
+// attribute, or in traceparent if both are present. Only traceparent and
+// tracestate are propagated downstream.
+func (s *server) cloudTraceOneWayPropagator(ctx context.Context, req request) *response {
+	return s.propagate(ctx, req, "cloudTraceOneWayPropagator", propagation.NewCompositeTextMapPropagator(
+		gcppropagator.CloudTraceOneWayPropagator{},
+		propagation.TraceContext{},
+	))
+}
+
+// propagate extracts the request attributes with the propagator, creates a
+// span with the name and injects the outgoing context into the response.
+func (s *server) propagate(
+	ctx context.Context,
+	req request,
+	spanName string,
+	propagator propagation.TextMapPropagator,
+) *response {
+	ctx = propagator.Extract(ctx, propagation.HeaderCarrier(req.headers))
+	attrs := []attribute.KeyValue{testIDAttr(req)}
+	for _, member := range baggage.FromContext(ctx).Members() {
+		attrs = append(attrs, attribute.String(member.Key(), member.Value()))
+	}
+
+	tracer := s.tracerProvider.Tracer(instrumentationName)
+	ctx, span := tracer.Start(ctx, spanName, trace.WithAttributes(attrs...))
+	span.End()
+
+	res := traceResponse(span)
+	propagator.Inject(ctx, propagation.MapCarrier(res.headers))
+	return res
+}
+
+// detectResource creates a single span named resourceDetectionTrace with the
+// GCP detected resource.
+func (s *server) detectResource(ctx context.Context, req request) *response {
+	tracer := s.detectingTracerProvider.Tracer(instrumentationName)
+	_, span := tracer.Start(ctx, "resourceDetectionTrace", trace.WithAttributes(testIDAttr(req)))
+	span.End()
+	return traceResponse(span)
+}
+
+func testIDAttr(req request) attribute.KeyValue {
+	return attribute.String(testclient.TestID, req.testID)
+}
+
+func traceResponse(span trace.Span) *response {
+	return &response{
+		statusCode: code.Code_OK,
+		headers:    map[string]string{traceIDKey: span.SpanContext().TraceID().String()},
+	}
+}
+
+// basicMetric adds 1 to an int64 counter named basicMetric.
+func (s *server) basicMetric(ctx context.Context, req request) *response {
+	return s.recordMetrics(ctx, resource.Empty(), func(provider metric.MeterProvider) error {
+		counter, err := provider.Meter(instrumentationName).Int64Counter("basicMetric")
+		if err != nil {
+			return err
+		}
+		counter.Add(ctx, 1, metric.WithAttributes(testIDAttr(req)))
+		return nil
+	})
+}
+
+// histogramMetric records 3 measurements to a float64 histogram named
+// histogramMetric.
+func (s *server) histogramMetric(ctx context.Context, req request) *response {
+	return s.recordMetrics(ctx, resource.Empty(), func(provider metric.MeterProvider) error {
+		histogram, err := provider.Meter(instrumentationName).Float64Histogram("histogramMetric")
+		if err != nil {
+			return err
+		}
+		for _, value := range []float64{1, 10, 100} {
+			histogram.Record(ctx, value, metric.WithAttributes(testIDAttr(req)))
+		}
+		return nil
+	})
+}
+
+// metricResourceDetection adds 1 to an int64 counter named
+// metricResourceDetection with the GCP detected resource.
+func (s *server) metricResourceDetection(ctx context.Context, req request) *response {
+	return s.recordMetrics(ctx, s.detected, func(provider metric.MeterProvider) error {
+		counter, err := provider.Meter(instrumentationName).Int64Counter("metricResourceDetection")
+		if err != nil {
+			return err
+		}
+		counter.Add(ctx, 1, metric.WithAttributes(testIDAttr(req)))
+		return nil
+	})
+}
+
+// basicLog writes a single WARNING log entry with the payload basicLog.
+func (s *server) basicLog(ctx context.Context, req request) *response {
+	err := s.logWriter.LogSync(ctx, logging.Entry{
+		Severity: logging.Warning,
+		Payload:  "basicLog",
+		Labels:   map[string]string{testclient.TestID: req.testID},
+	})
+	if err != nil {
+		return &response{statusCode: code.Code_INTERNAL}
+	}
+	return &response{statusCode: code.Code_OK}
+}
+
+// logTraceCorrelation writes a single INFO log entry with the payload
+// logTraceCorrelation inside a span named logTraceCorrelation, correlated to
+// the span through the trace and spanId fields.
+func (s *server) logTraceCorrelation(ctx context.Context, req request) *response {
+	tracer := s.tracerProvider.Tracer(instrumentationName)
+	_, span := tracer.Start(ctx, "logTraceCorrelation", trace.WithAttributes(testIDAttr(req)))
+	defer span.End()
+
+	sc := span.SpanContext()
+	err := s.logWriter.LogSync(ctx, logging.Entry{
+		Severity:     logging.Info,
+		Payload:      "logTraceCorrelation",
+		Labels:       map[string]string{testclient.TestID: req.testID},
+		Trace:        fmt.Sprintf("projects/%v/traces/%v", s.projectID, sc.TraceID()),
+		SpanID:       sc.SpanID().String(),
+		TraceSampled: sc.IsSampled(),
+	})
+	if err != nil {
+		return &response{statusCode: code.Code_INTERNAL}
+	}
+	res := traceResponse(span)
+	res.headers[spanIDKey] = sc.SpanID().String()
+	return res
+}
@@ -0,0 +1,277 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/logging"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
+	mexporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/metric"
+	texporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
+	"go.opentelemetry.io/contrib/detectors/gcp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	cloudTraceEndpointEnv = "CLOUD_TRACE_ENDPOINT"
+	logName               = "e2e-referenceserver"
+)
+
+// request is a scenario request received over any of the transports
+type request struct {
+	testID string
+	// The request attributes, used to carry propagation headers
+	headers http.Header
+}
+
+// response is sent back to the runner as attributes alongside test_id and
+// status_code
+type response struct {
+	statusCode code.Code
+	headers    map[string]string
+}
+
+type scenarioHandler func(ctx context.Context, req request) *response
+
+// logWriter is implemented by *logging.Logger
+type logWriter interface {
+	LogSync(ctx context.Context, e logging.Entry) error
+}
+
+type server struct {
+	// Exports spans without any resource, so only the span's own attributes
+	// end up as labels
+	tracerProvider *sdktrace.TracerProvider
+	// Exports spans with the GCP detected resource
+	detectingTracerProvider *sdktrace.TracerProvider
+	// Exports the metrics of each scenario, nil if metrics can't be exported
+	metricExporter sdkmetric.Exporter
+	// The GCP detected resource, for the metric scenarios
+	detected *resource.Resource
+	// Writes log entries with the detected monitored resource, nil if logs
+	// can't be written
+	logWriter logWriter
+
+	projectID string
+
+	handlers map[string]scenarioHandler
+}
+
+func newServerFromEnv(ctx context.Context, projectID string) (*server, error) {
+	opts := []texporter.Option{texporter.WithProjectID(projectID)}
+	if endpoint := os.Getenv(cloudTraceEndpointEnv); endpoint != "" {
+		opts = append(opts, texporter.WithTraceClientOptions([]option.ClientOption{
+			option.WithEndpoint(endpoint),
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		}))
+	}
+	exporter, err := texporter.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// There is no fake Cloud Monitoring backend, so metric scenarios are left
+	// out when the exporter can't be created, e.g. without credentials in
+	// hermetic runs
+	metricExporter, err := mexporter.New(mexporter.WithProjectID(projectID))
+	if err != nil {
+		log.Printf("Metric scenarios disabled, failed to create metric exporter: %v", err)
+		metricExporter = nil
+	}
+
+	// Same for logging, the client detects the monitored resource itself
+	var logs logWriter
+	loggingClient, err := logging.NewClient(ctx, projectID)
+	if err != nil {
+		log.Printf("Log scenarios disabled, failed to create logging client: %v", err)
+	} else {
+		logs = loggingClient.Logger(logName)
+	}
+
+	detected, err := resource.New(ctx, resource.WithDetectors(gcp.NewDetector()))
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, err
+	}
+	return newServer(projectID, exporter, metricExporter, logs, detected), nil
+}
+
+func newServer(
+	projectID string,
+	exporter sdktrace.SpanExporter,
+	metricExporter sdkmetric.Exporter, // optional
+	logs logWriter, // optional
+	detected *resource.Resource,
+) *server {
+	s := &server{
+		projectID: projectID,
+		logWriter: logs,
+		tracerProvider: sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(resource.Empty()),
+		),
+		detectingTracerProvider: sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(detected),
+		),
+	}
+	s.handlers = map[string]scenarioHandler{
+		testclient.Health:             s.health,
+		testclient.Capabilities:       s.capabilities,
+		"/basicTrace":                 s.basicTrace,
+		"/complexTrace":               s.complexTrace,
+		"/richTrace":                  s.richTrace,
+		"/basicPropagator":            s.basicPropagator,
+		"/w3cPropagator":              s.w3cPropagator,
+		"/baggagePropagator":          s.baggagePropagator,
+		"/cloudTraceOneWayPropagator": s.cloudTraceOneWayPropagator,
+		"/detectResource":             s.detectResource,
+	}
+
+	if metricExporter != nil {
+		s.metricExporter = metricExporter
+		s.detected = detected
+		s.handlers["/basicMetric"] = s.basicMetric
+		s.handlers["/histogramMetric"] = s.histogramMetric
+		s.handlers["/metricResourceDetection"] = s.metricResourceDetection
+	}
+	if logs != nil {
+		s.handlers["/basicLog"] = s.basicLog
+		s.handlers["/logTraceCorrelation"] = s.logTraceCorrelation
+	}
+	return s
+}
+
+func (s *server) handle(ctx context.Context, scenario string, req request) *response {
+	handler, ok := s.handlers[scenario]
+	if !ok {
+		return &response{statusCode: code.Code_UNIMPLEMENTED}
+	}
+	res := handler(ctx, req)
+
+	// The runner queries the backend right after getting the response
+	if err := s.forceFlush(ctx); err != nil {
+		return &response{statusCode: code.Code_INTERNAL}
+	}
+	return res
+}
+
+// provider is implemented by the tracer providers
+type provider interface {
+	ForceFlush(context.Context) error
+	Shutdown(context.Context) error
+}
+
+func (s *server) providers() []provider {
+	return []provider{s.tracerProvider, s.detectingTracerProvider}
+}
+
+func (s *server) forceFlush(ctx context.Context) error {
+	for _, p := range s.providers() {
+		if err := p.ForceFlush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) shutdown(ctx context.Context) {
+	for _, p := range s.providers() {
+		p.Shutdown(ctx)
+	}
+	if s.metricExporter != nil {
+		s.metricExporter.Shutdown(ctx)
+	}
+}
+
+// recordMetrics runs record with a MeterProvider of its own and exports what it
+// recorded once. A long-lived cumulative provider would export the series of
+// earlier scenarios again on every flush, more often than Cloud Monitoring
+// accepts points for a series.
+func (s *server) recordMetrics(
+	ctx context.Context,
+	res *resource.Resource,
+	record func(provider metric.MeterProvider) error,
+) *response {
+	reader := sdkmetric.NewManualReader(
+		sdkmetric.WithTemporalitySelector(s.metricExporter.Temporality),
+		sdkmetric.WithAggregationSelector(s.metricExporter.Aggregation),
+	)
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(res))
+	defer provider.Shutdown(ctx)
+
+	if err := record(provider); err != nil {
+		return &response{statusCode: code.Code_INTERNAL}
+	}
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(ctx, &rm); err != nil {
+		return &response{statusCode: code.Code_INTERNAL}
+	}
+	if err := s.metricExporter.Export(ctx, &rm); err != nil {
+		log.Printf("Failed to export metrics: %v", err)
+		return &response{statusCode: code.Code_INTERNAL}
+	}
+	return &response{statusCode: code.Code_OK}
+}
+
+func (s *server) health(ctx context.Context, req request) *response {
+	return &response{statusCode: code.Code_OK}
+}
+
+// capabilities lists the implemented scenarios and the versions of the
+// OpenTelemetry modules this binary was built with.
+func (s *server) capabilities(ctx context.Context, req request) *response {
+	var scenarios []string
+	for scenario := range s.handlers {
+		if scenario != testclient.Health && scenario != testclient.Capabilities {
+			scenarios = append(scenarios, scenario)
+		}
+	}
+	sort.Strings(scenarios)
+
+	headers := map[string]string{
+		testclient.Scenarios: strings.Join(scenarios, ","),
+		"language":           "go",
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			switch dep.Path {
+			case "go.opentelemetry.io/otel/sdk":
+				headers["sdk_version"] = dep.Version
+			case "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace":
+				headers["trace_exporter_version"] = dep.Version
+			case "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/metric":
+				headers["metric_exporter_version"] = dep.Version
+			}
+		}
+	}
+	return &response{statusCode: code.Code_OK, headers: headers}
+}
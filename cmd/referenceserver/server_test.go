@@ -0,0 +1,392 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/code"
+)
+
+// newTestServer serves the reference server over the HTTP transport and
+// returns a testclient for it, along with the exporters the telemetry ends up
+// in.
+func newTestServer(t *testing.T) (*testclient.Client, *tracetest.InMemoryExporter, *fakeMetricExporter, *fakeLogWriter) {
+	exporter := tracetest.NewInMemoryExporter()
+	metricExporter := &fakeMetricExporter{}
+	logs := &fakeLogWriter{}
+	s := newServer("fake-project", exporter, metricExporter, logs, resource.Empty())
+	t.Cleanup(func() { s.shutdown(context.Background()) })
+
+	srv := httptest.NewServer(httpHandler(s))
+	t.Cleanup(srv.Close)
+	return testclient.NewHTTP(srv.URL), exporter, metricExporter, logs
+}
+
+// fakeLogWriter keeps the written log entries in memory
+type fakeLogWriter struct {
+	mu      sync.Mutex
+	entries []logging.Entry
+}
+
+func (w *fakeLogWriter) LogSync(ctx context.Context, e logging.Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = append(w.entries, e)
+	return nil
+}
+
+func (w *fakeLogWriter) getEntries() []logging.Entry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]logging.Entry(nil), w.entries...)
+}
+
+// fakeMetricExporter keeps the exported metrics in memory
+type fakeMetricExporter struct {
+	mu      sync.Mutex
+	metrics map[string]metricdata.Metrics
+}
+
+var _ sdkmetric.Exporter = (*fakeMetricExporter)(nil)
+
+func (e *fakeMetricExporter) Temporality(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(k)
+}
+
+func (e *fakeMetricExporter) Aggregation(k sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(k)
+}
+
+func (e *fakeMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.metrics == nil {
+		e.metrics = map[string]metricdata.Metrics{}
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			e.metrics[m.Name] = m
+		}
+	}
+	return nil
+}
+
+func (e *fakeMetricExporter) ForceFlush(ctx context.Context) error { return nil }
+
+func (e *fakeMetricExporter) Shutdown(ctx context.Context) error { return nil }
+
+func (e *fakeMetricExporter) get(name string) (metricdata.Metrics, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	m, ok := e.metrics[name]
+	return m, ok
+}
+
+func spansByName(spans tracetest.SpanStubs) map[string]tracetest.SpanStub {
+	byName := map[string]tracetest.SpanStub{}
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	return byName
+}
+
+func TestBasicTrace(t *testing.T) {
+	client, exporter, _, _ := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{Scenario: "/basicTrace", TestID: "123"})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "basicTrace", spans[0].Name)
+	assert.Equal(t, spans[0].SpanContext.TraceID().String(), res.Headers[traceIDKey])
+	assert.Contains(t, spans[0].Attributes, attribute.String(testclient.TestID, "123"))
+}
+
+func TestComplexTrace(t *testing.T) {
+	client, exporter, _, _ := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{Scenario: "/complexTrace", TestID: "123"})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	byName := spansByName(exporter.GetSpans())
+	require.Len(t, byName, 4)
+	root := byName["complexTrace/root"]
+	assert.False(t, root.Parent.IsValid())
+	assert.Equal(t, root.SpanContext.SpanID(), byName["complexTrace/child1"].Parent.SpanID())
+	assert.Equal(t, trace.SpanKindServer, byName["complexTrace/child1"].SpanKind)
+	assert.Equal(t, byName["complexTrace/child1"].SpanContext.SpanID(), byName["complexTrace/child2"].Parent.SpanID())
+	assert.Equal(t, trace.SpanKindClient, byName["complexTrace/child2"].SpanKind)
+	assert.Equal(t, root.SpanContext.SpanID(), byName["complexTrace/child3"].Parent.SpanID())
+}
+
+func TestRichTrace(t *testing.T) {
+	client, exporter, _, _ := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{Scenario: "/richTrace", TestID: "123"})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	byName := spansByName(exporter.GetSpans())
+	require.Len(t, byName, 3)
+	root, linked, child := byName["richTrace/root"], byName["richTrace/linked"], byName["richTrace/child"]
+	assert.Equal(t, root.SpanContext.TraceID().String(), res.Headers[traceIDKey])
+	assert.Equal(t, codes.Unset, root.Status.Code)
+	assert.Equal(t, sdktrace.Status{Code: codes.Error, Description: "richTrace error"}, child.Status)
+	require.Len(t, child.Events, 1)
+	assert.Equal(t, "richTrace/event", child.Events[0].Name)
+	assert.Contains(t, child.Events[0].Attributes, attribute.String("event_key", "event_value"))
+	require.Len(t, child.Links, 1)
+	assert.Equal(t, linked.SpanContext.SpanID(), child.Links[0].SpanContext.SpanID())
+	assert.Contains(t, child.Attributes, attribute.String("richTrace/long", strings.Repeat("a", 300)))
+}
+
+func TestBasicPropagator(t *testing.T) {
+	client, exporter, _, _ := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{
+		Scenario: "/basicPropagator",
+		TestID:   "123",
+		Headers:  map[string]string{"X-Cloud-Trace-Context": "0af7651916cd43dd8448eb211c80319c/12345;o=1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", spans[0].SpanContext.TraceID().String())
+	assert.Equal(t, "0000000000003039", spans[0].Parent.SpanID().String())
+}
+
+func TestW3CPropagator(t *testing.T) {
+	for _, tc := range []struct {
+		flags       string
+		expectSpans int
+	}{
+		{flags: "01", expectSpans: 1},
+		{flags: "00", expectSpans: 0},
+	} {
+		t.Run("flags "+tc.flags, func(t *testing.T) {
+			client, exporter, _, _ := newTestServer(t)
+
+			res, err := client.Request(context.Background(), testclient.Request{
+				Scenario: "/w3cPropagator",
+				TestID:   "123",
+				Headers: map[string]string{
+					"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-" + tc.flags,
+					"tracestate":  "foo=bar",
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, code.Code_OK, res.StatusCode)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, tc.expectSpans)
+			assert.Regexp(t, `^00-0af7651916cd43dd8448eb211c80319c-[0-9a-f]{16}-`+tc.flags+`$`, res.Headers["traceparent"])
+			assert.NotContains(t, res.Headers["traceparent"], "b7ad6b7169203331")
+			assert.Equal(t, "foo=bar", res.Headers["tracestate"])
+			if tc.expectSpans > 0 {
+				assert.Equal(t, "w3cPropagator", spans[0].Name)
+				assert.Equal(t, "b7ad6b7169203331", spans[0].Parent.SpanID().String())
+			}
+		})
+	}
+}
+
+func TestBaggagePropagator(t *testing.T) {
+	client, exporter, _, _ := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{
+		Scenario: "/baggagePropagator",
+		TestID:   "123",
+		Headers: map[string]string{
+			"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			"baggage":     "e2e_baggage=456",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes, attribute.String("e2e_baggage", "456"))
+	assert.Equal(t, "e2e_baggage=456", res.Headers["baggage"])
+}
+
+func TestCloudTraceOneWayPropagator(t *testing.T) {
+	client, exporter, _, _ := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{
+		Scenario: "/cloudTraceOneWayPropagator",
+		TestID:   "123",
+		Headers:  map[string]string{"X-Cloud-Trace-Context": "0af7651916cd43dd8448eb211c80319c/12345;o=1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "0000000000003039", spans[0].Parent.SpanID().String())
+	assert.Regexp(t, `^00-0af7651916cd43dd8448eb211c80319c-[0-9a-f]{16}-01$`, res.Headers["traceparent"])
+	assert.NotContains(t, res.Headers, "x-cloud-trace-context")
+}
+
+func TestBasicMetric(t *testing.T) {
+	client, _, metricExporter, _ := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{Scenario: "/basicMetric", TestID: "123"})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	m, ok := metricExporter.get("basicMetric")
+	require.True(t, ok, "basicMetric was not exported")
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	require.True(t, ok, "expected an int64 sum, got %T", m.Data)
+	assert.True(t, sum.IsMonotonic)
+	require.Len(t, sum.DataPoints, 1)
+	assert.EqualValues(t, 1, sum.DataPoints[0].Value)
+	assert.Equal(t, attribute.NewSet(attribute.String(testclient.TestID, "123")), sum.DataPoints[0].Attributes)
+}
+
+func TestMetricScenariosDontReexport(t *testing.T) {
+	client, _, metricExporter, _ := newTestServer(t)
+
+	for _, testID := range []string{"123", "456"} {
+		res, err := client.Request(context.Background(), testclient.Request{Scenario: "/basicMetric", TestID: testID})
+		require.NoError(t, err)
+		assert.Equal(t, code.Code_OK, res.StatusCode)
+	}
+
+	// Only the second scenario's series, the first one's isn't written again
+	m, ok := metricExporter.get("basicMetric")
+	require.True(t, ok, "basicMetric was not exported")
+	sum := m.Data.(metricdata.Sum[int64])
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, attribute.NewSet(attribute.String(testclient.TestID, "456")), sum.DataPoints[0].Attributes)
+}
+
+func TestHistogramMetric(t *testing.T) {
+	client, _, metricExporter, _ := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{Scenario: "/histogramMetric", TestID: "123"})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	m, ok := metricExporter.get("histogramMetric")
+	require.True(t, ok, "histogramMetric was not exported")
+	histogram, ok := m.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected a float64 histogram, got %T", m.Data)
+	require.Len(t, histogram.DataPoints, 1)
+	assert.EqualValues(t, 3, histogram.DataPoints[0].Count)
+	assert.Equal(t, attribute.NewSet(attribute.String(testclient.TestID, "123")), histogram.DataPoints[0].Attributes)
+}
+
+func TestMetricsDisabledWithoutExporter(t *testing.T) {
+	s := newServer("fake-project", tracetest.NewInMemoryExporter(), nil, nil, resource.Empty())
+	t.Cleanup(func() { s.shutdown(context.Background()) })
+
+	for _, scenario := range []string{"/basicMetric", "/basicLog"} {
+		res := s.handle(context.Background(), scenario, request{testID: "123"})
+		assert.Equalf(t, code.Code_UNIMPLEMENTED, res.statusCode, "expected scenario %v to be disabled", scenario)
+	}
+}
+
+func TestBasicLog(t *testing.T) {
+	client, _, _, logs := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{Scenario: "/basicLog", TestID: "123"})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	entries := logs.getEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logging.Warning, entries[0].Severity)
+	assert.Equal(t, "basicLog", entries[0].Payload)
+	assert.Equal(t, "123", entries[0].Labels[testclient.TestID])
+}
+
+func TestLogTraceCorrelation(t *testing.T) {
+	client, exporter, _, logs := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{Scenario: "/logTraceCorrelation", TestID: "123"})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	entries := logs.getEntries()
+	require.Len(t, entries, 1)
+	traceID := spans[0].SpanContext.TraceID().String()
+	spanID := spans[0].SpanContext.SpanID().String()
+	assert.Equal(t, "projects/fake-project/traces/"+traceID, entries[0].Trace)
+	assert.Equal(t, spanID, entries[0].SpanID)
+	assert.True(t, entries[0].TraceSampled)
+	assert.Equal(t, traceID, res.Headers[traceIDKey])
+	assert.Equal(t, spanID, res.Headers[spanIDKey])
+}
+
+func TestCapabilities(t *testing.T) {
+	client, _, _, _ := newTestServer(t)
+
+	capabilities, err := client.Capabilities(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, capabilities)
+	for _, scenario := range []string{
+		"/basicTrace",
+		"/complexTrace",
+		"/richTrace",
+		"/basicPropagator",
+		"/w3cPropagator",
+		"/baggagePropagator",
+		"/cloudTraceOneWayPropagator",
+		"/detectResource",
+		"/basicMetric",
+		"/histogramMetric",
+		"/metricResourceDetection",
+		"/basicLog",
+		"/logTraceCorrelation",
+	} {
+		assert.Truef(t, capabilities.Supports(scenario), "expected scenario %v to be supported", scenario)
+	}
+	assert.False(t, capabilities.Supports(testclient.Health))
+	assert.Equal(t, "go", capabilities.Attributes["language"])
+}
+
+func TestUnimplementedScenario(t *testing.T) {
+	client, _, _, _ := newTestServer(t)
+
+	res, err := client.Request(context.Background(), testclient.Request{Scenario: "/notAScenario", TestID: "123"})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_UNIMPLEMENTED, res.StatusCode)
+}
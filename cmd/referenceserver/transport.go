@@ -0,0 +1,137 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
+)
+
+// runPull pulls requests from the request subscription and publishes the
+// responses to the response topic.
+func runPull(
+	ctx context.Context,
+	s *server,
+	projectID string,
+	requestSubscriptionName string,
+	responseTopicName string,
+	logger *log.Logger,
+) error {
+	client, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	responseTopic := client.Topic(responseTopicName)
+	defer responseTopic.Stop()
+
+	logger.Printf("Pulling requests from subscription %v\n", requestSubscriptionName)
+	return client.Subscription(requestSubscriptionName).Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+		msg.Ack()
+		publishResponse(ctx, s, responseTopic, msg.Attributes, logger)
+	})
+}
+
+// pushEnvelope is the body Pub/Sub sends to push endpoints
+type pushEnvelope struct {
+	Message struct {
+		Attributes map[string]string `json:"attributes"`
+	} `json:"message"`
+}
+
+// runPush serves a Pub/Sub push endpoint on the port and publishes the
+// responses to the response topic.
+func runPush(
+	ctx context.Context,
+	s *server,
+	projectID string,
+	port string,
+	responseTopicName string,
+	logger *log.Logger,
+) error {
+	client, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	responseTopic := client.Topic(responseTopicName)
+	defer responseTopic.Stop()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		envelope := pushEnvelope{}
+		if err := json.NewDecoder(r.Body).Decode(&envelope); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		publishResponse(r.Context(), s, responseTopic, envelope.Message.Attributes, logger)
+	})
+	logger.Printf("Listening for push requests on port %v\n", port)
+	return http.ListenAndServe(":"+port, handler)
+}
+
+func publishResponse(
+	ctx context.Context,
+	s *server,
+	responseTopic *pubsub.Topic,
+	attributes map[string]string,
+	logger *log.Logger,
+) {
+	headers := http.Header{}
+	for k, v := range attributes {
+		headers.Set(k, v)
+	}
+	res := s.handle(ctx, attributes[testclient.Scenario], request{
+		testID:  attributes[testclient.TestID],
+		headers: headers,
+	})
+
+	resAttributes := map[string]string{
+		testclient.TestID:     attributes[testclient.TestID],
+		testclient.StatusCode: strconv.Itoa(int(res.statusCode)),
+	}
+	for k, v := range res.headers {
+		resAttributes[k] = v
+	}
+	_, err := responseTopic.Publish(ctx, &pubsub.Message{Attributes: resAttributes}).Get(ctx)
+	if err != nil {
+		logger.Printf("Failed to publish response: %v\n", err)
+	}
+}
+
+// runHTTP serves scenarios directly, see testclient.HTTPTransport.
+func runHTTP(s *server, port string, logger *log.Logger) error {
+	logger.Printf("Listening for direct HTTP requests on port %v\n", port)
+	return http.ListenAndServe(":"+port, httpHandler(s))
+}
+
+func httpHandler(s *server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := s.handle(r.Context(), r.URL.Path, request{
+			testID:  r.Header.Get(testclient.TestID),
+			headers: r.Header,
+		})
+		w.Header().Set(testclient.TestID, r.Header.Get(testclient.TestID))
+		w.Header().Set(testclient.StatusCode, strconv.Itoa(int(res.statusCode)))
+		for k, v := range res.headers {
+			w.Header().Set(k, v)
+		}
+	})
+}
@@ -0,0 +1,210 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/report"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/cloudbuild/v1"
+)
+
+// How many of a trigger's builds to look through for finished ones
+const maxBuildsListed = 200
+
+var (
+	triggerNameRe  = regexp.MustCompile(`^ops-\w+-e2e-.*$`)
+	knownPlatforms = []string{
+		"local",
+		"gke",
+		"gce",
+		"gae",
+		"gae-standard",
+		"cloud-run",
+		"cloud-functions-gen2",
+	}
+)
+
+// cloudBuildSource reads the reports of the most recent finished builds of each
+// e2e trigger. The builds copy the --report-dir files to their logs bucket, see
+// reportLocation.
+type cloudBuildSource struct {
+	projectID         string
+	cloudbuildService *cloudbuild.Service
+	storageClient     *storage.Client
+}
+
+func (c *cloudBuildSource) Reports(ctx context.Context, builds int) ([]repoReport, error) {
+	// Don't bother going over pages, just use a large page size and look at the
+	// first page
+	listTriggersRes, err := c.cloudbuildService.Projects.Triggers.List(c.projectID).
+		Context(ctx).
+		PageSize(128).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	g, egCtx := errgroup.WithContext(ctx)
+	results := make([][]repoReport, len(listTriggersRes.Triggers))
+	for i, trigger := range listTriggersRes.Triggers {
+		g.Go(func() error {
+			res, err := c.handleTrigger(egCtx, trigger, builds)
+			if err != nil {
+				return err
+			}
+			results[i] = res
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var reports []repoReport
+	for _, res := range results {
+		reports = append(reports, res...)
+	}
+	return reports, nil
+}
+
+// handleTrigger returns the reports of the latest builds of the given trigger.
+func (c *cloudBuildSource) handleTrigger(ctx context.Context, trigger *cloudbuild.BuildTrigger, builds int) ([]repoReport, error) {
+	if !triggerNameRe.MatchString(trigger.Name) {
+		log.Printf("Skipping trigger %v which doesn't match regex", trigger.Name)
+		return nil, nil
+	}
+	platform := ""
+	for _, tag := range trigger.Tags {
+		if slices.Contains(knownPlatforms, tag) {
+			platform = tag
+			break
+		}
+	}
+	if platform == "" || trigger.Github == nil {
+		log.Printf("Skipping trigger %v", trigger.Name)
+		return nil, nil
+	}
+
+	// fetch the latest finished builds, failed builds have failing tests to
+	// show
+	finished, err := c.finishedBuilds(ctx, trigger.Id, builds)
+	if err != nil {
+		return nil, err
+	}
+	if len(finished) == 0 {
+		log.Printf("trigger %v had no recently finished builds, its scenarios are not run", trigger.Name)
+		return []repoReport{{RepoName: trigger.Github.Name, Platform: platform}}, nil
+	}
+
+	var reports []repoReport
+	for _, build := range finished {
+		res := repoReport{
+			RepoName: trigger.Github.Name,
+			Platform: platform,
+		}
+		res.Time, _ = time.Parse(time.RFC3339, build.CreateTime)
+		location := reportLocation(build)
+		if location == "" {
+			log.Printf("build %v of trigger %v has no logs bucket or artifacts, its scenarios are not run", build.Id, trigger.Name)
+			reports = append(reports, res)
+			continue
+		}
+		res.Report, err = c.readReport(ctx, location)
+		if err != nil {
+			return nil, fmt.Errorf("build %v of trigger %v: %w", build.Id, trigger.Name, err)
+		}
+		if res.Report == nil {
+			log.Printf("build %v of trigger %v has no report in %v, its scenarios are not run", build.Id, trigger.Name, location)
+		}
+		reports = append(reports, res)
+	}
+	return reports, nil
+}
+
+// Returns where the build uploaded its --report-dir files. The e2e builds copy
+// them to reports/BUILD_ID in their logs bucket in a final step, which also
+// runs when the tests failed. Older builds uploaded them as artifacts, which
+// Cloud Build only does when every step succeeds.
+func reportLocation(build *cloudbuild.Build) string {
+	if build.Artifacts != nil && build.Artifacts.Objects != nil && build.Artifacts.Objects.Location != "" {
+		return build.Artifacts.Objects.Location
+	}
+	if build.LogsBucket == "" {
+		return ""
+	}
+	return fmt.Sprintf("%v/reports/%v", strings.TrimSuffix(build.LogsBucket, "/"), build.Id)
+}
+
+// Returns up to the given number of the trigger's latest builds which
+// succeeded or failed, skipping running and cancelled builds
+func (c *cloudBuildSource) finishedBuilds(ctx context.Context, triggerID string, builds int) ([]*cloudbuild.Build, error) {
+	var finished []*cloudbuild.Build
+	listed := 0
+	errDone := errors.New("done")
+	err := c.cloudbuildService.Projects.Builds.List(c.projectID).
+		Filter(fmt.Sprintf(`trigger_id="%v"`, triggerID)).
+		PageSize(int64(max(builds, 10))).
+		Pages(ctx, func(res *cloudbuild.ListBuildsResponse) error {
+			for _, build := range res.Builds {
+				if build.Status == "SUCCESS" || build.Status == "FAILURE" {
+					finished = append(finished, build)
+				}
+				listed++
+				if len(finished) == builds || listed == maxBuildsListed {
+					return errDone
+				}
+			}
+			return nil
+		})
+	if err != nil && !errors.Is(err, errDone) {
+		return nil, err
+	}
+	return finished, nil
+}
+
+// Reads the report files from a gs://bucket/path location, or returns nil if
+// there are none
+func (c *cloudBuildSource) readReport(ctx context.Context, location string) (*report.Report, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "gs://"), "/")
+	for _, name := range []string{report.JSONFile, report.JUnitFile} {
+		object := path.Join(prefix, name)
+		reader, err := c.storageClient.Bucket(bucket).Object(object).NewReader(ctx)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		return parseReport(fmt.Sprintf("gs://%v/%v", bucket, object), data)
+	}
+	return nil, nil
+}
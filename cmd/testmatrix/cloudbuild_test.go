@@ -0,0 +1,53 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/cloudbuild/v1"
+)
+
+func TestReportLocation(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		build  *cloudbuild.Build
+		expect string
+	}{
+		{
+			name:   "logs bucket",
+			build:  &cloudbuild.Build{Id: "abc", LogsBucket: "gs://logs"},
+			expect: "gs://logs/reports/abc",
+		},
+		{
+			name: "artifacts",
+			build: &cloudbuild.Build{
+				Id:         "abc",
+				LogsBucket: "gs://logs",
+				Artifacts:  &cloudbuild.Artifacts{Objects: &cloudbuild.ArtifactObjects{Location: "gs://artifacts/abc"}},
+			},
+			expect: "gs://artifacts/abc",
+		},
+		{
+			name:  "default logs bucket",
+			build: &cloudbuild.Build{Id: "abc"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, reportLocation(tc.build))
+		})
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Sparkline bars from a 0% to 100% pass rate
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline point of a build without any passing or failing scenarios
+const sparkNotRun = '·'
+
+type history struct {
+	// How many builds of each trigger were fetched
+	Builds int
+	// Scenarios which both passed and failed, most flips first
+	Flaky  []*scenarioHistory
+	Trends []*trend
+}
+
+// scenarioHistory is how a scenario did on a repo and platform across builds.
+// Builds where the scenario was skipped or not run don't count.
+type scenarioHistory struct {
+	RepoName string
+	Platform string
+	Scenario string
+	Passes   int
+	Fails    int
+	// How often the scenario went from passing to failing or back
+	Flips int
+	last  status
+}
+
+func (h *scenarioHistory) add(s status) {
+	if s != pass && s != fail {
+		return
+	}
+	if h.last != "" && h.last != s {
+		h.Flips++
+	}
+	h.last = s
+	if s == pass {
+		h.Passes++
+	} else {
+		h.Fails++
+	}
+}
+
+func (h *scenarioHistory) passRate() float64 {
+	return float64(h.Passes) / float64(h.Passes+h.Fails)
+}
+
+// PassRate formats the pass rate, e.g. 80% (4/5)
+func (h *scenarioHistory) PassRate() string {
+	return formatPassRate(h.Passes, h.Passes+h.Fails)
+}
+
+// trend is how many scenarios passed in each build of a repo on a platform.
+type trend struct {
+	RepoName string
+	Platform string
+	// A bar per build, oldest first
+	Sparkline string
+	passes    int
+	runs      int
+}
+
+func (t *trend) add(passes, runs int) {
+	t.passes += passes
+	t.runs += runs
+	if runs == 0 {
+		t.Sparkline += string(sparkNotRun)
+		return
+	}
+	rate := float64(passes) / float64(runs)
+	t.Sparkline += string(sparkBars[int(math.Round(rate*float64(len(sparkBars)-1)))])
+}
+
+// PassRate formats the pass rate over all of the builds, e.g. 80% (4/5)
+func (t *trend) PassRate() string {
+	return formatPassRate(t.passes, t.runs)
+}
+
+func formatPassRate(passes, runs int) string {
+	if runs == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%% (%v/%v)", 100*float64(passes)/float64(runs), passes, runs)
+}
+
+// buildHistory computes the pass rates and flips of each scenario, and trends
+// of each repo and platform, from the reports of several builds.
+func buildHistory(reports []repoReport, builds int) *history {
+	type scenarioKey struct {
+		reportKey
+		Scenario string
+	}
+	scenarios := map[scenarioKey]*scenarioHistory{}
+	trends := map[reportKey]*trend{}
+	trendFor := func(key reportKey) *trend {
+		if trends[key] == nil {
+			trends[key] = &trend{RepoName: key.RepoName, Platform: key.Platform}
+		}
+		return trends[key]
+	}
+
+	for _, group := range groupReports(reports) {
+		// Every build of the group gets a point on the trends of all of the
+		// platforms any of its builds reported, e.g. a matrix build which failed
+		// before running some of its platforms
+		cells := make([]map[string]map[string]cell, len(group))
+		known := map[string]bool{}
+		for i, r := range group {
+			cells[i] = r.cells()
+			for platform := range cells[i] {
+				known[platform] = true
+			}
+		}
+		if len(known) == 0 {
+			known[group[0].Platform] = true
+		}
+
+		// Oldest first to count the flips
+		for i := len(group) - 1; i >= 0; i-- {
+			platformToScenario := cells[i]
+			for _, platform := range sortedKeys(known) {
+				passes, runs := 0, 0
+				for _, scenario := range sortedKeys(platformToScenario[platform]) {
+					s := platformToScenario[platform][scenario].Status
+					key := scenarioKey{reportKey{group[i].RepoName, platform}, scenario}
+					if scenarios[key] == nil {
+						scenarios[key] = &scenarioHistory{RepoName: group[i].RepoName, Platform: platform, Scenario: scenario}
+					}
+					scenarios[key].add(s)
+					if s == pass || s == fail {
+						runs++
+					}
+					if s == pass {
+						passes++
+					}
+				}
+				trendFor(reportKey{group[i].RepoName, platform}).add(passes, runs)
+			}
+		}
+	}
+
+	h := &history{Builds: builds}
+	for _, scenario := range scenarios {
+		if scenario.Passes > 0 && scenario.Fails > 0 {
+			h.Flaky = append(h.Flaky, scenario)
+		}
+	}
+	sort.Slice(h.Flaky, func(i, j int) bool {
+		a, b := h.Flaky[i], h.Flaky[j]
+		if a.Flips != b.Flips {
+			return a.Flips > b.Flips
+		}
+		if a.passRate() != b.passRate() {
+			return a.passRate() < b.passRate()
+		}
+		if a.RepoName != b.RepoName {
+			return a.RepoName < b.RepoName
+		}
+		if a.Platform != b.Platform {
+			return a.Platform < b.Platform
+		}
+		return a.Scenario < b.Scenario
+	})
+
+	for _, t := range trends {
+		h.Trends = append(h.Trends, t)
+	}
+	sort.Slice(h.Trends, func(i, j int) bool {
+		a, b := h.Trends[i], h.Trends[j]
+		if a.RepoName != b.RepoName {
+			return a.RepoName < b.RepoName
+		}
+		return a.Platform < b.Platform
+	})
+	return h
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var buildStart = time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+// A report of build n of the repo on gke, with the scenarios' statuses
+func gkeReport(repoName string, n int, statuses map[string]report.Status) repoReport {
+	rep := &report.Report{
+		Platform:  report.Platform{Name: "gke", TestRunID: fmt.Sprint(n)},
+		StartTime: buildStart.Add(time.Duration(n) * time.Hour),
+	}
+	for _, scenario := range sortedKeys(statuses) {
+		rep.Results = append(rep.Results, report.Result{Name: scenario, Platform: rep.Platform, Status: statuses[scenario]})
+	}
+	return repoReport{RepoName: repoName, Platform: "gke", Report: rep, Time: rep.StartTime}
+}
+
+// Builds 4 to 1 of the go repo, newest first like ReportSource returns them
+func goHistory() []repoReport {
+	return []repoReport{
+		gkeReport(goRepo, 4, map[string]report.Status{"TestBasicTrace": report.Pass, "TestComplexTrace": report.Pass, "TestBasicPropagator": report.Skip}),
+		gkeReport(goRepo, 3, map[string]report.Status{"TestBasicTrace": report.Fail, "TestComplexTrace": report.Pass, "TestBasicPropagator": report.Skip}),
+		// Failed before running tests
+		{RepoName: goRepo, Platform: "gke", Time: buildStart.Add(2 * time.Hour)},
+		gkeReport(goRepo, 1, map[string]report.Status{"TestBasicTrace": report.Pass, "TestComplexTrace": report.Fail, "TestBasicPropagator": report.Skip}),
+	}
+}
+
+func TestBuildHistory(t *testing.T) {
+	reports := append(goHistory(),
+		gkeReport(pythonRepo, 2, map[string]report.Status{"TestBasicTrace": report.Pass}),
+		gkeReport(pythonRepo, 1, map[string]report.Status{"TestBasicTrace": report.Pass}),
+	)
+	h := buildHistory(reports, 4)
+
+	assert.Equal(t, 4, h.Builds)
+	require.Len(t, h.Flaky, 2)
+	// Passed, failed then passed again
+	assert.Equal(t, "TestBasicTrace", h.Flaky[0].Scenario)
+	assert.Equal(t, 2, h.Flaky[0].Flips)
+	assert.Equal(t, "67% (2/3)", h.Flaky[0].PassRate())
+	assert.Equal(t, "TestComplexTrace", h.Flaky[1].Scenario)
+	assert.Equal(t, 1, h.Flaky[1].Flips)
+
+	require.Len(t, h.Trends, 2)
+	assert.Equal(t, goRepo, h.Trends[0].RepoName)
+	assert.Equal(t, "gke", h.Trends[0].Platform)
+	assert.Equal(t, "▅·▅█", h.Trends[0].Sparkline)
+	assert.Equal(t, "67% (4/6)", h.Trends[0].PassRate())
+	assert.Equal(t, "██", h.Trends[1].Sparkline)
+}
+
+// A report of build n of the repo's matrix, with the statuses of
+// TestBasicTrace on each platform
+func matrixReport(repoName string, n int, statuses map[string]report.Status) repoReport {
+	rep := &report.Report{StartTime: buildStart.Add(time.Duration(n) * time.Hour)}
+	for _, platform := range sortedKeys(statuses) {
+		rep.Results = append(rep.Results, report.Result{
+			Name:     "TestMatrix/" + platform + "/TestBasicTrace",
+			Platform: report.Platform{Name: platform, TestRunID: fmt.Sprint(n)},
+			Status:   statuses[platform],
+		})
+	}
+	return repoReport{RepoName: repoName, Platform: "matrix", Report: rep, Time: rep.StartTime}
+}
+
+func TestBuildHistoryMatrixPlatformNotRun(t *testing.T) {
+	h := buildHistory([]repoReport{
+		matrixReport(goRepo, 3, map[string]report.Status{"gce": report.Pass, "gke": report.Pass}),
+		// gce wasn't set up
+		matrixReport(goRepo, 2, map[string]report.Status{"gke": report.Fail}),
+		// Failed before running tests
+		{RepoName: goRepo, Platform: "matrix", Time: buildStart.Add(time.Hour)},
+	}, 3)
+
+	require.Len(t, h.Trends, 2)
+	assert.Equal(t, "gce", h.Trends[0].Platform)
+	assert.Equal(t, "··█", h.Trends[0].Sparkline)
+	assert.Equal(t, "100% (1/1)", h.Trends[0].PassRate())
+	assert.Equal(t, "gke", h.Trends[1].Platform)
+	assert.Equal(t, "·▁█", h.Trends[1].Sparkline)
+}
+
+func TestLocalSourceHistory(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i, rep := range goHistory() {
+		if rep.Report == nil {
+			continue
+		}
+		data, err := json.Marshal(rep.Report)
+		require.NoError(t, err)
+		// Names which don't sort by time
+		fsys[fmt.Sprintf("%v/build-%v/report.json", goRepo, i)] = &fstest.MapFile{Data: data}
+	}
+	source := &localSource{fsys: fsys}
+
+	reports, err := source.Reports(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, "4", reports[0].Report.TestRunID)
+	assert.Equal(t, "3", reports[1].Report.TestRunID)
+
+	// The matrix only has the latest build
+	m := buildMatrix(reports)
+	assert.Equal(t, cell{Status: pass}, m.Cell(goRepo, "gke", "TestBasicTrace"))
+}
+
+func TestWriteMatrixHistory(t *testing.T) {
+	reports := goHistory()
+	var out strings.Builder
+	require.NoError(t, writeMatrix(&out, buildMatrix(reports), buildHistory(reports, 4)))
+
+	assert.Contains(t, out.String(), "## Flaky scenarios")
+	assert.Contains(t, out.String(), "| opentelemetry-operations-go | gke | TestBasicTrace | 67% (2/3) | 2 |")
+	assert.Contains(t, out.String(), "| opentelemetry-operations-go | gke | ▅·▅█ | 67% (4/6) |")
+	assert.Contains(t, out.String(), "A · is a build which didn't run any.")
+	assert.Contains(t, out.String(), "--history=4 > matrix.md")
+
+	// Only with history
+	out.Reset()
+	require.NoError(t, writeMatrix(&out, buildMatrix(reports), nil))
+	assert.NotContains(t, out.String(), "## Flaky scenarios")
+	assert.Contains(t, out.String(), "--project-id=opentelemetry-ops-e2e > matrix.md")
+}
+
+func TestWriteMatrixNoFlakes(t *testing.T) {
+	reports := []repoReport{gkeReport(goRepo, 1, map[string]report.Status{"TestBasicTrace": report.Pass})}
+	var out strings.Builder
+	require.NoError(t, writeMatrix(&out, buildMatrix(reports), buildHistory(reports, 10)))
+	assert.Contains(t, out.String(), "builds, most flips between passing and failing first.\n\nNone.\n")
+}
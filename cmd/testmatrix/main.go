@@ -12,38 +12,40 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// This script generates the matrix.md file from most recent build of each
-// trigger:
+// This script generates the matrix.md file from the test reports of the most
+// recent build of each trigger:
 //
 // ```bash
-//	go run cmd/testmatrix/main.go > matrix.md
+//	go run ./cmd/testmatrix > matrix.md
 // ```
 
 package main
 
 import (
-	"bufio"
 	"context"
-	"fmt"
-	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting"
 	"html/template"
-	"log"
+	"io"
 	"os"
-	"regexp"
-	"slices"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/report"
 	"github.com/alexflint/go-arg"
-	"golang.org/x/sync/errgroup"
 	"google.golang.org/api/cloudbuild/v1"
 )
 
 const (
-	pass        status = ":white_check_mark:"
-	skip        status = ":leftwards_arrow_with_hook:"
-	templateTxt        = `# Matrix of supported scenarios in each ops repo
+	pass   status = ":white_check_mark:"
+	fail   status = ":x:"
+	skip   status = ":leftwards_arrow_with_hook:"
+	notRun status = ":heavy_minus_sign:"
+
+	// Longer messages are cut short in the tooltips
+	maxMessageLength = 500
+
+	templateTxt = `# Matrix of supported scenarios in each ops repo
 
 <table>
 	<thead>
@@ -66,7 +68,9 @@ const (
 				{{- end }}
 				<td>{{ $platform }}</td>
 				{{- range $scenario := $.Scenarios }}
-					<td>{{ index $.RepoToPlatformToScenario $repoName $platform $scenario }}</td>
+					{{- with $.Cell $repoName $platform $scenario }}
+					<td{{ if .Message }} title="{{ .Message }}"{{ end }}>{{ .Status }}</td>
+					{{- end }}
 				{{- end }}
 			</tr>
 		{{- end }}
@@ -75,196 +79,240 @@ const (
 </table>
 
 - *{{ .Pass }} means passing*
+- *{{ .Fail }} means failing, hover for the failure message*
 - *{{ .Skip }} means not implemented (skipped)*
+- *{{ .NotRun }} means not run, e.g. the build failed before running tests*
+{{- with .History }}
+
+## Flaky scenarios
+
+Scenarios which both passed and failed in the last {{ .Builds }} builds, most flips between passing and failing first.
+{{ if .Flaky }}
+| Repo Name | Platform | Scenario | Pass rate | Flips |
+| --- | --- | --- | --- | --- |
+{{- range .Flaky }}
+| {{ .RepoName }} | {{ .Platform }} | {{ .Scenario }} | {{ .PassRate }} | {{ .Flips }} |
+{{- end }}
+{{ else }}
+None.
+{{ end }}
+## Trends
+
+Share of scenarios passing in each of the last {{ .Builds }} builds, oldest first. A {{ printf "%c" $.SparkNotRun }} is a build which didn't run any.
+
+| Repo Name | Platform | Trend | Pass rate |
+| --- | --- | --- | --- |
+{{- range .Trends }}
+| {{ .RepoName }} | {{ .Platform }} | {{ .Sparkline }} | {{ .PassRate }} |
+{{- end }}
+{{- end }}
 
 ## Regenerate
 
 To regenerate this matrix, run from the repo root:
 ` + "```sh" + `
-go run cmd/testmatrix/main.go --project-id=opentelemetry-ops-e2e > matrix.md
+go run ./cmd/testmatrix --project-id=opentelemetry-ops-e2e{{ with .History }} --history={{ .Builds }}{{ end }} > matrix.md
 ` + "```" + `
 
-This will fetch recent Cloud Build logs to automatically update the statuses in this matrix.
+This will fetch the test reports of recent Cloud Builds to automatically update the statuses in this matrix.
 `
 )
 
 type Args struct {
-	e2etesting.CmdWithProjectId
+	ProjectID string `arg:"--project-id,env:PROJECT_ID" help:"GCP project id/name whose Cloud Build triggers to read the reports of"`
+	ReportDir string `arg:"--report-dir" help:"Read the reports from this local directory instead, laid out as REPO_NAME/PATH/report.json or junit.xml"`
+	History   int    `arg:"--history" help:"Also read the reports of this many recent builds of each trigger, and add tables of flaky scenarios and pass rate trends"`
 }
 
 type status string
 
-type result struct {
-	RepoName string
-	Platform string
-	Statuses map[string]status
+type cell struct {
+	Status status
+	// The failure message or skip reason
+	Message string
 }
 
-var (
-	triggerNameRe  = regexp.MustCompile(`^ops-\w+-e2e-.*$`)
-	scenarioPassRe = regexp.MustCompile(`: --- PASS:\s+([\w_]+)`)
-	scenarioSkipRe = regexp.MustCompile(`: --- SKIP:\s+([\w_]+)`)
-	knownPlatforms = []string{
-		"local",
-		"gke",
-		"gce",
-		"gae",
-		"gae-standard",
-		"cloud-run",
-		"cloud-functions-gen2",
-	}
-)
+type matrix struct {
+	RepoNames                []string
+	Scenarios                []string
+	Platforms                []string
+	RepoToPlatformToScenario map[string]map[string]map[string]cell
+}
 
 func main() {
-	args := e2etesting.Args{}
-	arg.MustParse(&args)
+	args := Args{}
+	p := arg.MustParse(&args)
+	if args.ProjectID == "" && args.ReportDir == "" {
+		p.Fail("--project-id or --report-dir is required")
+	}
 
 	ctx := context.Background()
-	cloudbuildService, err := cloudbuild.NewService(ctx)
-	if err != nil {
-		panic(err)
-	}
-	storageClient, err := storage.NewClient(ctx)
-	if err != nil {
-		panic(err)
+	var source ReportSource
+	if args.ReportDir != "" {
+		source = &localSource{fsys: os.DirFS(args.ReportDir)}
+	} else {
+		cloudbuildService, err := cloudbuild.NewService(ctx)
+		if err != nil {
+			panic(err)
+		}
+		storageClient, err := storage.NewClient(ctx)
+		if err != nil {
+			panic(err)
+		}
+		source = &cloudBuildSource{
+			projectID:         args.ProjectID,
+			cloudbuildService: cloudbuildService,
+			storageClient:     storageClient,
+		}
 	}
 
-	// Don't bother going over pages, just use a large page size and look at the
-	// first page
-	listTriggersRes, err := cloudbuildService.Projects.Triggers.List(args.ProjectID).
-		Context(ctx).
-		PageSize(128).
-		Do()
+	reports, err := source.Reports(ctx, max(args.History, 1))
 	if err != nil {
 		panic(err)
 	}
-
-	g, egCtx := errgroup.WithContext(ctx)
-	results := make([]*result, len(listTriggersRes.Triggers))
-	for i, trigger := range listTriggersRes.Triggers {
-		i := i
-		trigger := trigger
-		g.Go(func() error {
-			res, err := handleTrigger(egCtx, args.ProjectID, trigger, cloudbuildService, storageClient)
-			if err != nil {
-				return err
-			}
-			results[i] = res
-			return nil
-		})
+	var h *history
+	if args.History > 0 {
+		h = buildHistory(reports, args.History)
 	}
-	if err := g.Wait(); err != nil {
+	if err := writeMatrix(os.Stdout, buildMatrix(reports), h); err != nil {
 		panic(err)
 	}
+}
 
-	repoToPlatformToScenario := map[string]map[string]map[string]status{}
+// buildMatrix sorts the latest report of each repo and platform into a cell
+// per repo, platform and scenario.
+func buildMatrix(reports []repoReport) *matrix {
+	repoToPlatformToScenario := map[string]map[string]map[string]cell{}
 	repoNameSet := map[string]struct{}{}
 	scenarioSet := map[string]struct{}{}
 	platformSet := map[string]struct{}{}
-	for _, result := range results {
-		if result == nil || result.Platform == "build" {
+	for _, repoReport := range latestReports(reports) {
+		repoNameSet[repoReport.RepoName] = struct{}{}
+		if repoToPlatformToScenario[repoReport.RepoName] == nil {
+			repoToPlatformToScenario[repoReport.RepoName] = map[string]map[string]cell{}
+		}
+		platformToScenario := repoToPlatformToScenario[repoReport.RepoName]
+		if repoReport.Report == nil {
+			platformSet[repoReport.Platform] = struct{}{}
 			continue
 		}
 
-		repoNameSet[result.RepoName] = struct{}{}
-		if repoToPlatformToScenario[result.RepoName] == nil {
-			repoToPlatformToScenario[result.RepoName] = map[string]map[string]status{}
+		for platform, scenarioToCell := range repoReport.cells() {
+			platformSet[platform] = struct{}{}
+			platformToScenario[platform] = scenarioToCell
+			for scenario := range scenarioToCell {
+				scenarioSet[scenario] = struct{}{}
+			}
 		}
-		platformToScenario := repoToPlatformToScenario[result.RepoName]
+	}
+	return &matrix{
+		RepoNames:                sortStringSet(repoNameSet),
+		Scenarios:                sortStringSet(scenarioSet),
+		Platforms:                sortStringSet(platformSet),
+		RepoToPlatformToScenario: repoToPlatformToScenario,
+	}
+}
 
-		platformToScenario[result.Platform] = result.Statuses
-		platformSet[result.Platform] = struct{}{}
+// cells returns the status of each scenario in the report by platform.
+func (r *repoReport) cells() map[string]map[string]cell {
+	platformToScenario := map[string]map[string]cell{}
+	if r.Report == nil {
+		return platformToScenario
+	}
+	for _, result := range r.Report.Results {
+		scenario, subtest := scenarioName(result.Name)
+		if scenario == "" {
+			continue
+		}
+		platform := result.Platform.Name
+		if platform == "" {
+			platform = r.Platform
+		}
+		if platformToScenario[platform] == nil {
+			platformToScenario[platform] = map[string]cell{}
+		}
+		c := newCell(result)
 
-		for scenario := range result.Statuses {
-			scenarioSet[scenario] = struct{}{}
+		if subtest {
+			// Failing scenario tests often only have output in their
+			// subtests, which come after them
+			existing, ok := platformToScenario[platform][scenario]
+			if ok && existing.Status == fail && existing.Message == "" && c.Status == fail {
+				existing.Message = c.Message
+				platformToScenario[platform][scenario] = existing
+			}
+			continue
+		}
+		// e.g. a matrix run with gae on two runtimes, show the worst
+		if existing, ok := platformToScenario[platform][scenario]; !ok || statusRank[c.Status] > statusRank[existing.Status] {
+			platformToScenario[platform][scenario] = c
 		}
 	}
-	repoNames := sortStringSet(repoNameSet)
-	scenarios := sortStringSet(scenarioSet)
-	platforms := sortStringSet(platformSet)
+	return platformToScenario
+}
 
-	template := template.Must(template.New("table").Parse(templateTxt))
-	err = template.Execute(os.Stdout, struct {
-		RepoNames                []string
-		Scenarios                []string
-		Platforms                []string
-		RepoToPlatformToScenario map[string]map[string]map[string]status
-		Pass                     status
-		Skip                     status
-	}{repoNames, scenarios, platforms, repoToPlatformToScenario, pass, skip})
+var statusRank = map[status]int{skip: 1, pass: 2, fail: 3}
 
-	if err != nil {
-		panic(err)
+func newCell(result report.Result) cell {
+	c := cell{Message: truncate(strings.TrimSpace(result.Message), maxMessageLength)}
+	switch result.Status {
+	case report.Pass:
+		c.Status = pass
+	case report.Fail:
+		c.Status = fail
+	case report.Skip:
+		c.Status = skip
 	}
+	return c
 }
 
-// handleTrigger returns the latest results for the given trigger by querying
-// builds and logs.
-func handleTrigger(
-	ctx context.Context,
-	projectId string,
-	trigger *cloudbuild.BuildTrigger,
-	cloudbuildService *cloudbuild.Service,
-	storageClient *storage.Client,
-) (*result, error) {
-	if !triggerNameRe.MatchString(trigger.Name) {
-		log.Printf("Skipping trigger %v which doesn't match regex", trigger.Name)
-		return nil, nil
-	}
-	platform := ""
-	for _, tag := range trigger.Tags {
-		if slices.Contains(knownPlatforms, tag) {
-			platform = tag
-			break
-		}
-	}
-	if platform == "" || trigger.Github == nil {
-		log.Printf("Skipping trigger %v", trigger.Name)
-		return nil, nil
-	}
-	res := &result{
-		RepoName: trigger.Github.Name,
-		Platform: platform,
-		Statuses: make(map[string]status),
+// Cell returns what to show for the scenario, with scenarios missing from the
+// report shown as not run.
+func (m *matrix) Cell(repoName, platform, scenario string) cell {
+	c, ok := m.RepoToPlatformToScenario[repoName][platform][scenario]
+	if !ok {
+		return cell{Status: notRun}
 	}
+	return c
+}
 
-	// fetch the latest successful build
-	listRes, err := cloudbuildService.Projects.Builds.List(projectId).
-		Context(ctx).
-		Filter(fmt.Sprintf(`trigger_id="%v" AND status="SUCCESS"`, trigger.Id)).
-		PageSize(1).
-		Do()
-	if err != nil {
-		return nil, err
+// writeMatrix renders the matrix, and the flakiness and trend tables if h isn't
+// nil.
+func writeMatrix(w io.Writer, m *matrix, h *history) error {
+	template := template.Must(template.New("table").Parse(templateTxt))
+	return template.Execute(w, struct {
+		*matrix
+		History     *history
+		Pass        status
+		Fail        status
+		Skip        status
+		NotRun      status
+		SparkNotRun rune
+	}{m, h, pass, fail, skip, notRun, sparkNotRun})
+}
+
+// scenarioName returns the matrix column of a test, e.g. TestBasicTrace for
+// both TestBasicTrace and TestMatrix/gke/TestBasicTrace, and whether the test
+// is one of its subtests. Returns "" for TestMatrix itself and its platforms.
+func scenarioName(testName string) (string, bool) {
+	if rest, ok := strings.CutPrefix(testName, "TestMatrix/"); ok {
+		_, testName, _ = strings.Cut(rest, "/")
 	}
-	if len(listRes.Builds) == 0 {
-		log.Printf("trigger %v had no successful builds, skipping the trigger", trigger.Name)
-		return res, nil
+	if testName == "TestMatrix" {
+		return "", false
 	}
+	scenario, _, subtest := strings.Cut(testName, "/")
+	return scenario, subtest
+}
 
-	build := listRes.Builds[0]
-	reader, err := storageClient.Bucket(strings.TrimPrefix(build.LogsBucket, "gs://")).
-		Object(fmt.Sprintf("log-%v.txt", build.Id)).
-		NewReader(ctx)
-	if err != nil {
-		return nil, err
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
 	}
-	defer reader.Close()
-
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if passMatches := scenarioPassRe.FindStringSubmatch(line); passMatches != nil {
-			res.Statuses[passMatches[1]] = pass
-		} else if skipMatches := scenarioSkipRe.FindStringSubmatch(line); skipMatches != nil {
-			res.Statuses[skipMatches[1]] = skip
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
 	}
-
-	return res, nil
+	return s[:n] + "…"
 }
 
 func sortStringSet(set map[string]struct{}) []string {
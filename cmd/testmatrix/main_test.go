@@ -0,0 +1,125 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	goRepo     = "opentelemetry-operations-go"
+	pythonRepo = "opentelemetry-operations-python"
+)
+
+func localReports(t *testing.T) []repoReport {
+	source := &localSource{fsys: os.DirFS("testdata/reports")}
+	reports, err := source.Reports(context.Background(), 1)
+	require.NoError(t, err)
+	return reports
+}
+
+func TestLocalSource(t *testing.T) {
+	reports := localReports(t)
+	require.Len(t, reports, 2)
+
+	assert.Equal(t, goRepo, reports[0].RepoName)
+	assert.Equal(t, "gke", reports[0].Platform)
+	assert.Len(t, reports[0].Report.Results, 4)
+
+	// Read from the JUnit XML
+	assert.Equal(t, pythonRepo, reports[1].RepoName)
+	assert.Equal(t, "matrix", reports[1].Platform)
+	assert.Len(t, reports[1].Report.Results, 6)
+}
+
+func TestLocalSourceInvalidReport(t *testing.T) {
+	source := &localSource{fsys: fstest.MapFS{
+		"repo/gke/report.json": {Data: []byte("--- PASS: TestBasicTrace")},
+	}}
+	_, err := source.Reports(context.Background(), 1)
+	assert.ErrorContains(t, err, "invalid report repo/gke/report.json")
+}
+
+func TestBuildMatrix(t *testing.T) {
+	// A build which failed before writing its report
+	reports := append(localReports(t), repoReport{RepoName: goRepo, Platform: "cloud-run"})
+	m := buildMatrix(reports)
+
+	assert.Equal(t, []string{goRepo, pythonRepo}, m.RepoNames)
+	assert.Equal(t, []string{"cloud-run", "gae", "gke"}, m.Platforms)
+	assert.Equal(t, []string{"TestBasicPropagator", "TestBasicTrace", "TestComplexTrace"}, m.Scenarios)
+
+	assert.Equal(t, cell{Status: pass}, m.Cell(goRepo, "gke", "TestBasicTrace"))
+	// From the failed subtest
+	assert.Equal(t, cell{
+		Status:  fail,
+		Message: `trace_test.go:215: Span "<root>" is missing label test_id`,
+	}, m.Cell(goRepo, "gke", "TestComplexTrace"))
+	assert.Equal(t, skip, m.Cell(goRepo, "gke", "TestBasicPropagator").Status)
+	assert.Equal(t, cell{Status: notRun}, m.Cell(goRepo, "cloud-run", "TestBasicTrace"))
+	assert.Equal(t, cell{Status: notRun}, m.Cell(goRepo, "gae", "TestBasicTrace"))
+
+	assert.Equal(t, cell{Status: pass}, m.Cell(pythonRepo, "cloud-run", "TestBasicTrace"))
+	assert.Equal(t, cell{
+		Status:  fail,
+		Message: "propagator_test.go:130: Outgoing traceparent has the wrong trace ID",
+	}, m.Cell(pythonRepo, "cloud-run", "TestBasicPropagator"))
+	assert.Equal(t, cell{Status: pass}, m.Cell(pythonRepo, "gae", "TestBasicTrace"))
+	assert.Equal(t, cell{Status: notRun}, m.Cell(pythonRepo, "gke", "TestBasicTrace"))
+}
+
+func TestWriteMatrix(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, writeMatrix(&out, buildMatrix(localReports(t)), nil))
+
+	assert.Contains(t, out.String(), "<th>TestBasicPropagator</th>")
+	assert.Contains(t, out.String(), `<td title="propagator_test.go:130: Outgoing traceparent has the wrong trace ID">:x:</td>`)
+	assert.Contains(t, out.String(), `<td title="trace_test.go:96: test server does not support this scenario, skipping">:leftwards_arrow_with_hook:</td>`)
+	assert.Contains(t, out.String(), `<td title="trace_test.go:215: Span &#34;&lt;root&gt;&#34; is missing label test_id">:x:</td>`)
+	assert.Contains(t, out.String(), "<td>:heavy_minus_sign:</td>")
+}
+
+func TestScenarioName(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		scenario string
+		subtest  bool
+	}{
+		{"TestBasicTrace", "TestBasicTrace", false},
+		{"TestComplexTrace/Span_has_label_test_id", "TestComplexTrace", true},
+		{"TestMatrix", "", false},
+		{"TestMatrix/gke", "", false},
+		{"TestMatrix/gke/TestBasicTrace", "TestBasicTrace", false},
+		{"TestMatrix/gke/TestComplexTrace/Span", "TestComplexTrace", true},
+	} {
+		scenario, subtest := scenarioName(tc.name)
+		assert.Equalf(t, tc.scenario, scenario, "scenarioName(%q)", tc.name)
+		assert.Equalf(t, tc.subtest, subtest, "scenarioName(%q)", tc.name)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "ab…", truncate("abcdef", 2))
+	// Doesn't cut a rune in half
+	assert.Equal(t, "a…", truncate("aéb", 2))
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/report"
+)
+
+// repoReport is the test report of a build of a repo on a platform.
+type repoReport struct {
+	RepoName string
+	// The platform the report is expected to be for. Results in the report
+	// are shown under their own platform.
+	Platform string
+	// nil if there was no report, e.g. the build failed before writing it.
+	// All of the platform's scenarios are shown as not run.
+	Report *report.Report
+	// When the build ran
+	Time time.Time
+}
+
+// ReportSource is where the reports written by the test runner's --report-dir
+// are stored.
+type ReportSource interface {
+	// Reports returns the reports of up to the given number of latest builds
+	// of each repo and platform, newest first.
+	Reports(ctx context.Context, builds int) ([]repoReport, error)
+}
+
+type reportKey struct {
+	RepoName string
+	Platform string
+}
+
+// groupReports groups the reports by repo and platform, keeping the order of
+// the reports within each group. The groups are in order of first appearance.
+func groupReports(reports []repoReport) [][]repoReport {
+	var groups [][]repoReport
+	index := map[reportKey]int{}
+	for _, r := range reports {
+		key := reportKey{r.RepoName, r.Platform}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], r)
+	}
+	return groups
+}
+
+// latestReports returns the first report of each repo and platform.
+func latestReports(reports []repoReport) []repoReport {
+	var latest []repoReport
+	for _, group := range groupReports(reports) {
+		latest = append(latest, group[0])
+	}
+	return latest
+}
+
+// localSource reads reports from a directory laid out as
+// REPO_NAME/PATH/report.json, e.g. artifacts downloaded from each repo's
+// builds. Directories with both report files only use the JSONFile. Reports
+// for the same repo and platform are ordered by their start time.
+type localSource struct {
+	fsys fs.FS
+}
+
+func (l *localSource) Reports(ctx context.Context, builds int) ([]repoReport, error) {
+	var reports []repoReport
+	err := fs.WalkDir(l.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || name == "." {
+			return err
+		}
+		repoName, _, _ := strings.Cut(name, "/")
+		rep, err := l.read(name)
+		if err != nil || rep == nil {
+			return err
+		}
+		log.Printf("Read report for %v from %v", repoName, name)
+		reports = append(reports, repoReport{
+			RepoName: repoName,
+			Platform: rep.Platform.Name,
+			Report:   rep,
+			Time:     rep.StartTime,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var latest []repoReport
+	for _, group := range groupReports(reports) {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Time.After(group[j].Time) })
+		latest = append(latest, group[:min(builds, len(group))]...)
+	}
+	return latest, nil
+}
+
+// Returns nil if dir has no report
+func (l *localSource) read(dir string) (*report.Report, error) {
+	for _, name := range []string{report.JSONFile, report.JUnitFile} {
+		data, err := fs.ReadFile(l.fsys, path.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		return parseReport(path.Join(dir, name), data)
+	}
+	return nil, nil
+}
+
+func parseReport(name string, data []byte) (*report.Report, error) {
+	var rep *report.Report
+	var err error
+	if path.Base(name) == report.JUnitFile {
+		rep, err = report.ParseJUnit(data)
+	} else {
+		rep, err = report.ParseJSON(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid report %v: %w", name, err)
+	}
+	return rep, nil
+}
@@ -21,8 +21,12 @@ import (
 	"math/rand"
 	"os"
 	"strings"
+	"testing"
 	"time"
 
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/janitor"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/report"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
 	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
 	"github.com/alexflint/go-arg"
 )
@@ -31,6 +35,12 @@ type ApplyPersistent struct {
 	AutoApprove bool `arg:"--auto-approve" default:"false" help:"Approve without prompting. Default is false."`
 }
 
+type JanitorCmd struct {
+	MaxAge time.Duration `arg:"--max-age" default:"6h" help:"Destroy terraform workspaces whose state was last written longer ago than this"`
+	DryRun bool          `arg:"--dry-run" help:"Only log what would be destroyed"`
+	TfDir  string        `arg:"--tf-dir" default:"tf" help:"Directory holding the tf/ config directories"`
+}
+
 type CmdWithProjectId struct {
 	ProjectID string `arg:"required,--project-id,env:PROJECT_ID" help:"GCP project id/name"`
 }
@@ -39,6 +49,20 @@ type CmdWithImage struct {
 	Image string `arg:"required" help:"docker container image to deploy and test"`
 }
 
+// The collector configs are in tf/modules/otel-config
+type CmdWithCollectorConfig struct {
+	ConfigVariant string `arg:"--config-variant" default:"default" help:"Collector config to deploy and test: default, batch-memorylimiter, googlemanagedprometheus or otlp"`
+}
+
+// Deploys cmd/loadgen next to the collector to send it a known amount of
+// telemetry
+type CmdWithLoadgen struct {
+	LoadgenImage        string `arg:"--loadgen-image" help:"Optional cmd/loadgen image to run next to the collector, whose telemetry the smoke tests count"`
+	LoadgenSpans        int    `arg:"--loadgen-spans" default:"1000" help:"How many spans the load generator sends"`
+	LoadgenMetricSeries int    `arg:"--loadgen-metric-series" default:"100" help:"How many metric time series the load generator sends a point for"`
+	LoadgenLogs         int    `arg:"--loadgen-logs" default:"1000" help:"How many log records the load generator sends"`
+}
+
 type LocalCmd struct {
 	CmdWithImage
 
@@ -51,6 +75,12 @@ type LocalCmd struct {
 	Network string `help:"Docker network to use when starting the container, optional"`
 
 	ContainerUser string `arg:"--container-user" help:"Optional user to use when running the container"`
+
+	Transport string `arg:"--transport" default:"pubsub" help:"How to send requests to the test server, either pubsub or http. http skips creating Pub/Sub resources."`
+
+	// Hermetic runs don't need a GCP project, tfstate bucket or credentials
+	Hermetic     bool   `arg:"--hermetic" help:"Use in-process emulators instead of real GCP resources, no cloud access needed"`
+	EmulatorHost string `arg:"--emulator-host" default:"host.docker.internal" help:"Host name the test server container uses to reach emulators started by the runner in --hermetic mode"`
 }
 
 type GceCmd struct {
@@ -59,18 +89,23 @@ type GceCmd struct {
 
 type GceCollectorCmd struct {
 	CmdWithImage
+	CmdWithCollectorConfig
 }
 
 type GceCollectorArmCmd struct {
 	CmdWithImage
+	CmdWithCollectorConfig
 }
 
 type GkeCollectorCmd struct {
 	CmdWithImage
+	CmdWithCollectorConfig
+	CmdWithLoadgen
 }
 
 type GkeOperatorCollectorCmd struct {
 	CmdWithImage
+	CmdWithCollectorConfig
 }
 
 type GkeCmd struct {
@@ -95,6 +130,7 @@ type CloudRunCmd struct {
 
 type CloudRunCollectorCmd struct {
 	CmdWithImage
+	CmdWithCollectorConfig
 }
 
 type CloudFunctionsGen2Cmd struct {
@@ -111,6 +147,10 @@ type Args struct {
 	// applies the persistent resources which are used across tests. See
 	// tf/persistent/README.md for details on what is in there.
 	ApplyPersistent *ApplyPersistent `arg:"subcommand:apply-persistent" help:"Terraform apply the resources in tf/persistent and exit (does not run tests)."`
+	// Also doesn't run any tests. Cleans up the terraform workspaces and
+	// Pub/Sub topics left behind by test runs which were killed before their
+	// cleanup ran.
+	Janitor *JanitorCmd `arg:"subcommand:janitor" help:"Destroy leftover terraform workspaces and Pub/Sub topics of killed test runs and exit (does not run tests)."`
 
 	Local                *LocalCmd                `arg:"subcommand:local" help:"Deploy the test server locally with docker and execute tests"`
 	Gke                  *GkeCmd                  `arg:"subcommand:gke" help:"Deploy the test server on GKE and execute tests"`
@@ -124,17 +164,124 @@ type Args struct {
 	CloudRun             *CloudRunCmd             `arg:"subcommand:cloud-run" help:"Deploy the test server on Cloud Run and execute tests"`
 	CloudRunCollector    *CloudRunCollectorCmd    `arg:"subcommand:cloud-run-collector" help:"Deploy the collector on Cloud Run and execute tests"`
 	CloudFunctionsGen2   *CloudFunctionsGen2Cmd   `arg:"subcommand:cloud-functions-gen2" help:"Deploy the test server on Cloud Function (2nd Gen) and execute tests"`
+	Matrix               *MatrixCmd               `arg:"subcommand:matrix" help:"Deploy the test server on several platforms at once and execute tests against each"`
 
 	CmdWithProjectId
-	GoTestFlags         string        `help:"go test flags to pass through, e.g. --gotestflags='-test.v'"`
-	HealthCheckTimeout  time.Duration `arg:"--health-check-timeout" help:"A duration (e.g. 5m) to wait for the test server health check. Default is 2m." default:"15m"`
-	TraceBackoffInitial time.Duration `arg:"--trace-backoff-initial" help:"Initial exponential backoff duration for trace retries" default:"1s"`
-	TraceBackoffTotal   time.Duration `arg:"--trace-backoff-total" help:"Total maximum duration for trace retries" default:"60s"`
+	GoTestFlags          string        `help:"go test flags to pass through, e.g. --gotestflags='-test.v'"`
+	IacBinary            string        `arg:"--iac-binary" help:"IaC CLI to apply the tf/ modules with, e.g. terraform or tofu. Defaults to terraform if it's on the PATH, otherwise tofu"`
+	HealthCheckTimeout   time.Duration `arg:"--health-check-timeout" help:"A duration (e.g. 5m) to wait for the test server health check, or for the collector to start exporting its own metrics" default:"15m"`
+	TraceBackoffInitial  time.Duration `arg:"--trace-backoff-initial" help:"Initial exponential backoff duration for trace retries" default:"1s"`
+	TraceBackoffTotal    time.Duration `arg:"--trace-backoff-total" help:"Total maximum duration for trace retries" default:"60s"`
+	MetricBackoffInitial time.Duration `arg:"--metric-backoff-initial" help:"Initial exponential backoff duration for metric retries" default:"1s"`
+	MetricBackoffTotal   time.Duration `arg:"--metric-backoff-total" help:"Total maximum duration for metric retries" default:"3m"`
+	LogBackoffInitial    time.Duration `arg:"--log-backoff-initial" help:"Initial exponential backoff duration for log retries" default:"1s"`
+	LogBackoffTotal      time.Duration `arg:"--log-backoff-total" help:"Total maximum duration for log retries" default:"2m"`
 	// This is used in a new terraform workspace's name and in the GCP resources
 	// we create. Pass the GCB build ID in CI to get the build id formatted into
 	// resources created for debugging. If not provided, we generate a hex
 	// string.
 	TestRunID string `arg:"--test-run-id,env:TEST_RUN_ID" help:"Optional test run id to use to partition terraform resources"`
+	// For iterating on a test server or scenario without redeploying the
+	// environment every run. Kept environments are eventually destroyed by the
+	// janitor subcommand.
+	KeepEnvironment bool   `arg:"--keep-environment" help:"Don't destroy the terraform resources after the tests, and print the test run id to reuse them with"`
+	ReuseTestRunID  string `arg:"--reuse-test-run-id" help:"Run against the terraform resources kept by an earlier run with this test run id, only applying if the image or other inputs changed"`
+	ReportDir       string `arg:"--report-dir" help:"Optional directory to write the test results to, as report.json and junit.xml"`
+
+	// The subcommand's name, set by InitTestMain
+	subcommand string
+}
+
+// Platform returns the name of the subcommand the test server is deployed
+// with, e.g. "gke", or "" for subcommands which don't deploy a test server.
+func (a *Args) Platform() string {
+	switch {
+	case a.Local != nil:
+		return "local"
+	case a.Gce != nil:
+		return "gce"
+	case a.Gke != nil:
+		return "gke"
+	case a.CloudRun != nil:
+		return "cloud-run"
+	case a.CloudFunctionsGen2 != nil:
+		return "cloud-functions-gen2"
+	case a.Gae != nil:
+		return "gae"
+	case a.GaeStandard != nil:
+		return "gae-standard"
+	default:
+		return ""
+	}
+}
+
+// Image returns the container image under test, or the source zip for
+// platforms deployed from source.
+func (a *Args) Image() string {
+	switch {
+	case a.Local != nil:
+		return a.Local.Image
+	case a.Gce != nil:
+		return a.Gce.Image
+	case a.Gke != nil:
+		return a.Gke.Image
+	case a.CloudRun != nil:
+		return a.CloudRun.Image
+	case a.CloudFunctionsGen2 != nil:
+		return a.CloudFunctionsGen2.FunctionSource
+	case a.Gae != nil:
+		return a.Gae.Image
+	case a.GaeStandard != nil:
+		return a.GaeStandard.AppSource
+	case a.GceCollector != nil:
+		return a.GceCollector.Image
+	case a.GceCollectorArm != nil:
+		return a.GceCollectorArm.Image
+	case a.GkeCollector != nil:
+		return a.GkeCollector.Image
+	case a.GkeOperatorCollector != nil:
+		return a.GkeOperatorCollector.Image
+	case a.CloudRunCollector != nil:
+		return a.CloudRunCollector.Image
+	default:
+		return ""
+	}
+}
+
+// CollectorConfigVariant returns the collector subcommand's --config-variant,
+// or "default" if it wasn't set.
+func (a *Args) CollectorConfigVariant() string {
+	var variant string
+	switch {
+	case a.GceCollector != nil:
+		variant = a.GceCollector.ConfigVariant
+	case a.GceCollectorArm != nil:
+		variant = a.GceCollectorArm.ConfigVariant
+	case a.GkeCollector != nil:
+		variant = a.GkeCollector.ConfigVariant
+	case a.GkeOperatorCollector != nil:
+		variant = a.GkeOperatorCollector.ConfigVariant
+	case a.CloudRunCollector != nil:
+		variant = a.CloudRunCollector.ConfigVariant
+	}
+	if variant == "" {
+		return "default"
+	}
+	return variant
+}
+
+// ReportPlatform describes where the tests run for the --report-dir report.
+func (a *Args) ReportPlatform() report.Platform {
+	return report.Platform{Name: a.subcommand, Image: a.Image(), TestRunID: a.TestRunID}
+}
+
+// NewReportRecorder returns the recorder for the --report-dir report, or nil
+// if no report was asked for.
+func (a *Args) NewReportRecorder() *report.Recorder {
+	if a.ReportDir == "" {
+		return nil
+	}
+	return report.NewRecorder(a.ReportPlatform())
 }
 
 type Cleanup func()
@@ -160,6 +307,16 @@ func InitTestMain(args *Args, applyPersistent ApplyPersistentFunc) (*log.Logger,
 	if p.Subcommand() == nil {
 		p.Fail("missing command")
 	}
+	args.subcommand = strings.Join(p.SubcommandNames(), " ")
+	if args.ReuseTestRunID != "" {
+		if args.TestRunID != "" && args.TestRunID != args.ReuseTestRunID {
+			p.Fail("--test-run-id and --reuse-test-run-id must match when both are set")
+		}
+		args.TestRunID = args.ReuseTestRunID
+	}
+	setuptf.IacBinary = args.IacBinary
+	setuptf.KeepEnvironment = args.KeepEnvironment
+	setuptf.ReuseWorkspace = args.ReuseTestRunID != ""
 	// Need a logger just for TestMain() before testing.T is available
 	logger := log.New(os.Stdout, "TestMain: ", log.LstdFlags|log.Lshortfile)
 	ctx := context.Background()
@@ -173,6 +330,14 @@ func InitTestMain(args *Args, applyPersistent ApplyPersistentFunc) (*log.Logger,
 		return nil, nil, true
 	}
 
+	if args.Janitor != nil {
+		err := janitor.Clean(ctx, args.ProjectID, args.Janitor.TfDir, args.Janitor.MaxAge, args.Janitor.DryRun, logger)
+		if err != nil {
+			logger.Panic(err)
+		}
+		return nil, nil, true
+	}
+
 	// hacky but works
 	os.Args = append([]string{os.Args[0]}, strings.Fields(args.GoTestFlags)...)
 	flag.Parse()
@@ -187,3 +352,22 @@ func InitTestMain(args *Args, applyPersistent ApplyPersistentFunc) (*log.Logger,
 	}
 	return logger, ctx, false
 }
+
+// RunTests runs the tests between the output art. With a recorder from
+// NewReportRecorder, it then writes their results to --report-dir.
+func RunTests(m *testing.M, args *Args, recorder *report.Recorder, logger *log.Logger) {
+	logger.Print(BeginOutputArt)
+	_, err := report.Run(m, recorder)
+	logger.Print(EndOutputArt)
+	if recorder == nil {
+		return
+	}
+	if err != nil {
+		logger.Printf("Test results may be missing from the report: %v\n", err)
+	}
+	if err := recorder.Report().WriteFiles(args.ReportDir); err != nil {
+		logger.Printf("Failed to write the report to %v: %v\n", args.ReportDir, err)
+		return
+	}
+	logger.Printf("Wrote the report to %v\n", args.ReportDir)
+}
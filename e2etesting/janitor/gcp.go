@@ -0,0 +1,196 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
+)
+
+// GCPBackend finds workspaces in the terraform state bucket and topics with
+// the Pub/Sub API, and destroys workspaces with setuptf.DestroyWorkspace.
+type GCPBackend struct {
+	projectID     string
+	logger        *log.Logger
+	storageClient *storage.Client
+	pubsubClient  *pubsub.Client
+}
+
+var _ Backend = (*GCPBackend)(nil)
+
+func NewGCPBackend(ctx context.Context, projectID string, logger *log.Logger) (*GCPBackend, error) {
+	storageClient, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pubsubClient, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		storageClient.Close()
+		return nil, err
+	}
+	return &GCPBackend{
+		projectID:     projectID,
+		logger:        logger,
+		storageClient: storageClient,
+		pubsubClient:  pubsubClient,
+	}, nil
+}
+
+func (b *GCPBackend) Close() error {
+	b.pubsubClient.Close()
+	return b.storageClient.Close()
+}
+
+func (b *GCPBackend) Workspaces(ctx context.Context) ([]Workspace, error) {
+	bucket := b.storageClient.Bucket(setuptf.StateBucket(b.projectID))
+	prefix := setuptf.StatePrefix + "/"
+	it := bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	var workspaces []Workspace
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name, ok := strings.CutSuffix(strings.TrimPrefix(attrs.Name, prefix), ".tfstate")
+		if !ok || name == defaultWorkspace {
+			continue
+		}
+
+		configDir, empty, err := b.readState(ctx, bucket.Object(attrs.Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state of workspace %v: %w", name, err)
+		}
+		workspaces = append(workspaces, Workspace{
+			Name:      name,
+			Updated:   attrs.Updated,
+			ConfigDir: configDir,
+			Empty:     empty,
+		})
+	}
+	return workspaces, nil
+}
+
+func (b *GCPBackend) readState(ctx context.Context, object *storage.ObjectHandle) (string, bool, error) {
+	reader, err := object.NewReader(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	defer reader.Close()
+	state, err := io.ReadAll(reader)
+	if err != nil {
+		return "", false, err
+	}
+	return ReadState(state)
+}
+
+func (b *GCPBackend) Topics(ctx context.Context) ([]Topic, error) {
+	it := b.pubsubClient.Topics(ctx)
+	var topics []Topic
+	for {
+		topic, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		config, err := topic.Config(ctx)
+		if err != nil {
+			return nil, err
+		}
+		workspace, ok := config.Labels[WorkspaceLabel]
+		if !ok {
+			continue
+		}
+
+		var subscriptions []string
+		subIt := topic.Subscriptions(ctx)
+		for {
+			sub, err := subIt.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			subscriptions = append(subscriptions, sub.ID())
+		}
+		// Topics without a valid label are as old as can be
+		created, _ := time.Parse(CreatedAtLayout, config.Labels[CreatedAtLabel])
+		topics = append(topics, Topic{
+			Name:          topic.ID(),
+			Workspace:     workspace,
+			Created:       created,
+			Subscriptions: subscriptions,
+		})
+	}
+	return topics, nil
+}
+
+func (b *GCPBackend) DestroyWorkspace(ctx context.Context, workspace string, dir ConfigDir) error {
+	return setuptf.DestroyWorkspace(ctx, b.projectID, workspace, dir.Path, dir.PlaceholderVars(), b.logger)
+}
+
+func (b *GCPBackend) DeleteTopic(ctx context.Context, topic Topic) error {
+	for _, sub := range topic.Subscriptions {
+		if err := b.pubsubClient.Subscription(sub).Delete(ctx); err != nil {
+			return err
+		}
+	}
+	return b.pubsubClient.Topic(topic.Name).Delete(ctx)
+}
+
+// Clean runs a Janitor with a GCPBackend over the config directories in the
+// tf/ root.
+func Clean(
+	ctx context.Context,
+	projectID string,
+	tfRoot string,
+	maxAge time.Duration,
+	dryRun bool,
+	logger *log.Logger,
+) error {
+	dirs, err := LoadConfigDirs(tfRoot)
+	if err != nil {
+		return err
+	}
+	backend, err := NewGCPBackend(ctx, projectID, logger)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	j := &Janitor{
+		Backend: backend,
+		Dirs:    dirs,
+		MaxAge:  maxAge,
+		DryRun:  dryRun,
+		Logger:  logger,
+	}
+	return j.Run(ctx)
+}
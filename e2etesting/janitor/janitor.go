@@ -0,0 +1,309 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package janitor cleans up after test runs which were killed before their
+// cleanup ran, leaving behind a terraform workspace and the resources in it.
+//
+// All of the tf/ config directories share one state prefix, so every
+// workspace is listed once from the state bucket no matter which directory
+// created it. Each directory records its name in the config_dir output of the
+// workspace's state, which is the directory the workspace is destroyed with.
+package janitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The labels tf/modules/pubsub puts on the Pub/Sub resources it creates
+const (
+	WorkspaceLabel = "tf-workspace"
+	// When the resources were created, in CreatedAtLayout
+	CreatedAtLabel  = "created-at"
+	CreatedAtLayout = "20060102150405"
+)
+
+// The output of tf/common/main-common.tf with the name of the config directory
+const configDirOutput = "config_dir"
+
+// Config directory to delete workspaces with nothing in their state, which
+// don't record their directory. It only needs the Pub/Sub variables.
+const emptyStateDir = "local"
+
+// The workspace holding the persistent resources, which is never cleaned up
+const defaultWorkspace = "default"
+
+// Backend lists and deletes the leftover resources.
+type Backend interface {
+	// Workspaces returns the terraform workspaces which have state, besides
+	// the default workspace.
+	Workspaces(ctx context.Context) ([]Workspace, error)
+	// Topics returns the Pub/Sub topics with a WorkspaceLabel.
+	Topics(ctx context.Context) ([]Topic, error)
+
+	// DestroyWorkspace destroys the resources in the workspace with the config
+	// in dir and deletes the workspace.
+	DestroyWorkspace(ctx context.Context, workspace string, dir ConfigDir) error
+	// DeleteTopic deletes the topic and its subscriptions.
+	DeleteTopic(ctx context.Context, topic Topic) error
+}
+
+type Workspace struct {
+	Name string
+	// When the workspace's state was last written
+	Updated time.Time
+	// The config directory the state records, see ReadState
+	ConfigDir string
+	// Whether the state has no resources, e.g. of a run killed before apply
+	Empty bool
+}
+
+type Topic struct {
+	Name string
+	// The WorkspaceLabel value
+	Workspace string
+	// The CreatedAtLabel value, zero for topics created before the label
+	// existed
+	Created       time.Time
+	Subscriptions []string
+}
+
+// ConfigDir is one of the tf/ config directories test runs are applied from.
+type ConfigDir struct {
+	// e.g. tf/gke
+	Path string
+	// Input variables besides project_id
+	Variables []string
+	// The Variables with a default, which don't need a value to destroy
+	Defaulted map[string]bool
+}
+
+// PlaceholderVars returns a value for each of the directory's variables
+// without a default. Nothing is refreshed when destroying, so the values are
+// never used. Variables with a default keep it, since a placeholder could fail
+// their type or validation.
+func (d ConfigDir) PlaceholderVars() map[string]string {
+	vars := map[string]string{}
+	for _, variable := range d.Variables {
+		if !d.Defaulted[variable] {
+			vars[variable] = "janitor-placeholder"
+		}
+	}
+	return vars
+}
+
+type Janitor struct {
+	Backend Backend
+	Dirs    []ConfigDir
+	// Workspaces whose state was written more recently, and topics created
+	// more recently, are left alone
+	MaxAge time.Duration
+	// Only log what would be destroyed
+	DryRun bool
+	Logger *log.Logger
+
+	// For tests, defaults to time.Now
+	now func() time.Time
+}
+
+// Run destroys the workspaces older than MaxAge, and deletes the labelled
+// topics older than MaxAge whose workspace no longer exists. It keeps going
+// after errors and returns all of them.
+func (j *Janitor) Run(ctx context.Context) error {
+	// List topics before workspaces. A workspace's state is written before its
+	// topics are created, so a topic without a workspace here was orphaned
+	// rather than created by a run which started in between.
+	topics, err := j.Backend.Topics(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list topics: %w", err)
+	}
+	workspaces, err := j.Backend.Workspaces(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	now := time.Now
+	if j.now != nil {
+		now = j.now
+	}
+	var errs []error
+	existing := map[string]bool{}
+	for _, workspace := range workspaces {
+		existing[workspace.Name] = true
+		if workspace.Name == defaultWorkspace {
+			continue
+		}
+		age := now().Sub(workspace.Updated).Round(time.Second)
+		if age < j.MaxAge {
+			j.Logger.Printf("Keeping workspace %v, last written %v ago\n", workspace.Name, age)
+			continue
+		}
+
+		dir, err := ownerDir(j.Dirs, workspace)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if j.DryRun {
+			j.Logger.Printf("Would destroy workspace %v with %v, last written %v ago\n", workspace.Name, dir.Path, age)
+			continue
+		}
+		j.Logger.Printf("Destroying workspace %v with %v, last written %v ago\n", workspace.Name, dir.Path, age)
+		if err := j.Backend.DestroyWorkspace(ctx, workspace.Name, dir); err != nil {
+			errs = append(errs, fmt.Errorf("failed to destroy workspace %v: %w", workspace.Name, err))
+		}
+	}
+
+	for _, topic := range topics {
+		if existing[topic.Workspace] {
+			// Destroying the workspace takes care of it
+			continue
+		}
+		age := now().Sub(topic.Created).Round(time.Second)
+		if age < j.MaxAge {
+			j.Logger.Printf("Keeping topic %v of missing workspace %v, created %v ago\n", topic.Name, topic.Workspace, age)
+			continue
+		}
+		if j.DryRun {
+			j.Logger.Printf("Would delete topic %v of missing workspace %v\n", topic.Name, topic.Workspace)
+			continue
+		}
+		j.Logger.Printf("Deleting topic %v of missing workspace %v\n", topic.Name, topic.Workspace)
+		if err := j.Backend.DeleteTopic(ctx, topic); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete topic %v: %w", topic.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Finds the config directory the workspace's state records.
+func ownerDir(dirs []ConfigDir, workspace Workspace) (ConfigDir, error) {
+	name := workspace.ConfigDir
+	if name == "" {
+		if !workspace.Empty {
+			return ConfigDir{}, fmt.Errorf("workspace %v has resources but no %v output to destroy them with", workspace.Name, configDirOutput)
+		}
+		name = emptyStateDir
+	}
+	for _, dir := range dirs {
+		if filepath.Base(dir.Path) == name {
+			return dir, nil
+		}
+	}
+	return ConfigDir{}, fmt.Errorf("workspace %v was created from config directory %v, which doesn't exist", workspace.Name, name)
+}
+
+// Config directories under the tf/ root which test runs aren't applied from
+var nonRunDirs = map[string]bool{
+	"common":               true,
+	"modules":              true,
+	"persistent":           true,
+	"persistent-collector": true,
+}
+
+var (
+	variableRe = regexp.MustCompile(`(?m)^variable\s+"([^"]+)"\s*\{`)
+	defaultRe  = regexp.MustCompile(`(?m)^\s+default\s*=`)
+)
+
+// LoadConfigDirs reads the config directories test runs are applied from in
+// the tf/ root, e.g. tf/gce and tf/gke-collector.
+func LoadConfigDirs(root string) ([]ConfigDir, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []ConfigDir
+	for _, entry := range entries {
+		if !entry.IsDir() || nonRunDirs[entry.Name()] {
+			continue
+		}
+		dir, ok, err := loadConfigDir(filepath.Join(root, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// Reads the variables of a config directory, reporting false if it has no
+// config files. Only understands top level blocks starting at the beginning of
+// a line, which is how every config in tf/ is formatted.
+func loadConfigDir(path string) (ConfigDir, bool, error) {
+	files, err := filepath.Glob(filepath.Join(path, "*.tf"))
+	if err != nil || len(files) == 0 {
+		return ConfigDir{}, false, err
+	}
+	dir := ConfigDir{Path: path, Defaulted: map[string]bool{}}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return ConfigDir{}, false, err
+		}
+		for _, match := range variableRe.FindAllStringSubmatchIndex(string(src), -1) {
+			name := string(src[match[2]:match[3]])
+			if name == "project_id" {
+				continue
+			}
+			dir.Variables = append(dir.Variables, name)
+			if defaultRe.MatchString(blockBody(string(src[match[1]:]))) {
+				dir.Defaulted[name] = true
+			}
+		}
+	}
+	sort.Strings(dir.Variables)
+	return dir, true, nil
+}
+
+// Returns the body of a top level block, given the source after its opening
+// brace. The block ends at the first closing brace at the beginning of a line,
+// or on the same line for a one line block.
+func blockBody(src string) string {
+	line, _, _ := strings.Cut(src, "\n")
+	if i := strings.Index(line, "}"); i >= 0 {
+		return line[:i]
+	}
+	if i := strings.Index(src, "\n}"); i >= 0 {
+		return src[:i]
+	}
+	return src
+}
+
+// ReadState returns the config directory a terraform state file records in
+// its config_dir output, if any, and whether it has no resources.
+func ReadState(state []byte) (string, bool, error) {
+	parsed := struct {
+		Outputs map[string]struct {
+			Value any `json:"value"`
+		} `json:"outputs"`
+		Resources []json.RawMessage `json:"resources"`
+	}{}
+	if err := json.Unmarshal(state, &parsed); err != nil {
+		return "", false, err
+	}
+	configDir, _ := parsed.Outputs[configDirOutput].Value.(string)
+	return configDir, len(parsed.Resources) == 0, nil
+}
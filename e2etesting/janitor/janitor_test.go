@@ -0,0 +1,221 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package janitor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	workspaces []Workspace
+	topics     []Topic
+	destroyErr error
+
+	destroyed map[string]string
+	deleted   []string
+}
+
+func (f *fakeBackend) Workspaces(ctx context.Context) ([]Workspace, error) {
+	return f.workspaces, nil
+}
+
+func (f *fakeBackend) Topics(ctx context.Context) ([]Topic, error) {
+	return f.topics, nil
+}
+
+func (f *fakeBackend) DestroyWorkspace(ctx context.Context, workspace string, dir ConfigDir) error {
+	if f.destroyErr != nil {
+		return f.destroyErr
+	}
+	if f.destroyed == nil {
+		f.destroyed = map[string]string{}
+	}
+	f.destroyed[workspace] = dir.Path
+	return nil
+}
+
+func (f *fakeBackend) DeleteTopic(ctx context.Context, topic Topic) error {
+	f.deleted = append(f.deleted, topic.Name)
+	return nil
+}
+
+var now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func loadRepoConfigDirs(t *testing.T) []ConfigDir {
+	dirs, err := LoadConfigDirs(filepath.Join("..", "..", "tf"))
+	require.NoError(t, err)
+	return dirs
+}
+
+func newTestJanitor(t *testing.T, backend Backend, dryRun bool) *Janitor {
+	return &Janitor{
+		Backend: backend,
+		Dirs:    loadRepoConfigDirs(t),
+		MaxAge:  6 * time.Hour,
+		DryRun:  dryRun,
+		Logger:  log.New(io.Discard, "", 0),
+		now:     func() time.Time { return now },
+	}
+}
+
+func newTestBackend() *fakeBackend {
+	return &fakeBackend{
+		workspaces: []Workspace{
+			{Name: "old", Updated: now.Add(-7 * time.Hour), ConfigDir: "gce"},
+			{Name: "killed-early", Updated: now.Add(-24 * time.Hour), Empty: true},
+			{Name: "running", Updated: now.Add(-time.Hour), ConfigDir: "gce"},
+			{Name: "default", Updated: now.Add(-1000 * time.Hour)},
+		},
+		topics: []Topic{
+			{Name: "request-old", Workspace: "old", Created: now.Add(-7 * time.Hour)},
+			{Name: "request-running", Workspace: "running", Created: now.Add(-time.Hour)},
+			{Name: "request-gone", Workspace: "gone", Created: now.Add(-24 * time.Hour), Subscriptions: []string{"request-gone-pull"}},
+			// Created before the label existed
+			{Name: "request-unlabelled", Workspace: "unlabelled"},
+			// Its workspace may not be listed yet, e.g. if its state wasn't
+			// written yet
+			{Name: "request-new", Workspace: "new", Created: now.Add(-time.Minute)},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	backend := newTestBackend()
+	require.NoError(t, newTestJanitor(t, backend, false).Run(context.Background()))
+
+	assert.Equal(t, map[string]string{
+		"old":          filepath.Join("..", "..", "tf", "gce"),
+		"killed-early": filepath.Join("..", "..", "tf", "local"),
+	}, backend.destroyed)
+	assert.Equal(t, []string{"request-gone", "request-unlabelled"}, backend.deleted)
+}
+
+func TestRunDryRun(t *testing.T) {
+	backend := newTestBackend()
+	require.NoError(t, newTestJanitor(t, backend, true).Run(context.Background()))
+
+	assert.Empty(t, backend.destroyed)
+	assert.Empty(t, backend.deleted)
+}
+
+func TestRunKeepsGoingAfterErrors(t *testing.T) {
+	backend := newTestBackend()
+	backend.destroyErr = errors.New("destroy failed")
+	backend.workspaces = append(backend.workspaces,
+		Workspace{Name: "unrecorded", Updated: now.Add(-24 * time.Hour)},
+		Workspace{Name: "removed", Updated: now.Add(-24 * time.Hour), ConfigDir: "gone"},
+	)
+
+	err := newTestJanitor(t, backend, false).Run(context.Background())
+	assert.ErrorContains(t, err, "failed to destroy workspace old: destroy failed")
+	assert.ErrorContains(t, err, "failed to destroy workspace killed-early: destroy failed")
+	assert.ErrorContains(t, err, "workspace unrecorded has resources but no config_dir output")
+	assert.ErrorContains(t, err, "workspace removed was created from config directory gone, which doesn't exist")
+	assert.Equal(t, []string{"request-gone", "request-unlabelled"}, backend.deleted)
+}
+
+func TestLoadConfigDirs(t *testing.T) {
+	dirs := map[string]ConfigDir{}
+	for _, dir := range loadRepoConfigDirs(t) {
+		dirs[filepath.Base(dir.Path)] = dir
+	}
+
+	assert.NotContains(t, dirs, "persistent")
+	assert.NotContains(t, dirs, "modules")
+	assert.Contains(t, dirs, emptyStateDir)
+	// Which has the config_dir output
+	for _, dir := range dirs {
+		assert.FileExists(t, filepath.Join(dir.Path, "main-common.tf"))
+	}
+	assert.Equal(t, []string{"appsource", "entrypoint", "runtime"}, dirs["gae-standard"].Variables)
+	assert.Equal(t, map[string]string{"image": "janitor-placeholder"}, dirs["gce"].PlaceholderVars())
+	assert.Equal(t, map[string]string{
+		"appsource": "janitor-placeholder",
+		"runtime":   "janitor-placeholder",
+	}, dirs["gae-standard"].PlaceholderVars())
+	assert.Equal(t, map[string]string{"image": "janitor-placeholder"}, dirs["gke-collector"].PlaceholderVars())
+}
+
+func TestLoadConfigDirDefaultedVariables(t *testing.T) {
+	path := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(path, "main.tf"), []byte(`
+resource "google_compute_instance" "default" {}
+
+variable "image" {
+  type = string
+}
+
+variable "spans" {
+  type    = number
+  default = 0
+}
+
+variable "variant" {
+  type        = string
+  default     = "default"
+  description = "Must be one of the variants"
+
+  validation {
+    condition     = contains(["default", "other"], var.variant)
+    error_message = "Unknown variant."
+  }
+}
+
+variable "region" {}
+
+variable "enabled" { default = false }
+`), 0o644))
+
+	dir, ok, err := loadConfigDir(path)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []string{"enabled", "image", "region", "spans", "variant"}, dir.Variables)
+	assert.Equal(t, map[string]string{
+		"image":  "janitor-placeholder",
+		"region": "janitor-placeholder",
+	}, dir.PlaceholderVars())
+}
+
+func TestReadState(t *testing.T) {
+	configDir, empty, err := ReadState([]byte(`{
+  "version": 4,
+  "outputs": {
+    "config_dir": {"value": "gce", "type": "string"},
+    "pubsub_info": {"value": {}, "type": ["object", {}]}
+  },
+  "resources": [
+    {"mode": "managed", "type": "google_compute_instance", "name": "default"}
+  ]
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, "gce", configDir)
+	assert.False(t, empty)
+
+	// Killed before apply wrote anything
+	configDir, empty, err = ReadState([]byte(`{"version": 4, "outputs": {}, "resources": []}`))
+	require.NoError(t, err)
+	assert.Empty(t, configDir)
+	assert.True(t, empty)
+}
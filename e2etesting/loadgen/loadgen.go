@@ -0,0 +1,223 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package loadgen sends a known amount of OTLP telemetry to a collector, so the
+// collector tests can check all of it was exported. The requests are built by
+// hand instead of with the SDK, so that exactly what's configured is sent
+// without any batching or aggregation in between.
+package loadgen
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+)
+
+const (
+	// Attribute of all of the generated spans, metric points and log records,
+	// set to the test run ID
+	TestRunIDKey = "otelcol_google_e2e_loadgen"
+	// Name of all of the generated spans
+	SpanName = "loadgen"
+	// How many spans each generated trace has, the last trace may have fewer
+	SpansPerTrace = 10
+	// Monotonic sum with a point of value 1 for each series
+	MetricName = "loadgen.series"
+	// Attribute which tells the metric's series apart
+	SeriesKey = "loadgen_series"
+	// Attribute numbering the log records
+	LogIndexKey = "loadgen_index"
+
+	serviceName = "loadgen"
+)
+
+// Config is how much telemetry to send.
+type Config struct {
+	TestRunID    string
+	Spans        int
+	MetricSeries int
+	Logs         int
+	// Largest number of spans or log records in one export request
+	BatchSize int
+}
+
+// Run sends the configured telemetry to the OTLP gRPC receiver on conn. It
+// returns an error if any export fails or is partially rejected.
+func Run(ctx context.Context, conn grpc.ClientConnInterface, cfg Config) error {
+	if cfg.BatchSize <= 0 {
+		return fmt.Errorf("batch size must be positive, got %v", cfg.BatchSize)
+	}
+	if err := sendSpans(ctx, coltracepb.NewTraceServiceClient(conn), cfg); err != nil {
+		return fmt.Errorf("sending spans: %w", err)
+	}
+	if err := sendMetrics(ctx, colmetricspb.NewMetricsServiceClient(conn), cfg); err != nil {
+		return fmt.Errorf("sending metrics: %w", err)
+	}
+	if err := sendLogs(ctx, collogspb.NewLogsServiceClient(conn), cfg); err != nil {
+		return fmt.Errorf("sending logs: %w", err)
+	}
+	return nil
+}
+
+func sendSpans(ctx context.Context, client coltracepb.TraceServiceClient, cfg Config) error {
+	var traceID, rootSpanID []byte
+	for start := 0; start < cfg.Spans; start += cfg.BatchSize {
+		end := min(start+cfg.BatchSize, cfg.Spans)
+		now := time.Now()
+		spans := make([]*tracepb.Span, 0, end-start)
+		for i := start; i < end; i++ {
+			span := &tracepb.Span{
+				SpanId:            randomID(8),
+				Name:              SpanName,
+				Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+				StartTimeUnixNano: uint64(now.Add(-time.Second).UnixNano()),
+				EndTimeUnixNano:   uint64(now.UnixNano()),
+				Attributes:        attributes(cfg.TestRunID),
+			}
+			if i%SpansPerTrace == 0 {
+				traceID, rootSpanID = randomID(16), span.SpanId
+			} else {
+				span.ParentSpanId = rootSpanID
+			}
+			span.TraceId = traceID
+			spans = append(spans, span)
+		}
+		res, err := client.Export(ctx, &coltracepb.ExportTraceServiceRequest{
+			ResourceSpans: []*tracepb.ResourceSpans{{
+				Resource:   resource(),
+				ScopeSpans: []*tracepb.ScopeSpans{{Scope: scope(), Spans: spans}},
+			}},
+		}, grpc.WaitForReady(true))
+		if err != nil {
+			return err
+		}
+		if rejected := res.GetPartialSuccess().GetRejectedSpans(); rejected > 0 {
+			return fmt.Errorf("%v spans rejected: %v", rejected, res.GetPartialSuccess().GetErrorMessage())
+		}
+	}
+	return nil
+}
+
+// Sends a single request with a point for each series. Cloud Monitoring only
+// accepts a point per series every few seconds, so there is one point each.
+func sendMetrics(ctx context.Context, client colmetricspb.MetricsServiceClient, cfg Config) error {
+	if cfg.MetricSeries == 0 {
+		return nil
+	}
+	now := time.Now()
+	points := make([]*metricspb.NumberDataPoint, 0, cfg.MetricSeries)
+	for i := range cfg.MetricSeries {
+		points = append(points, &metricspb.NumberDataPoint{
+			Attributes: append(attributes(cfg.TestRunID), stringAttribute(SeriesKey, strconv.Itoa(i))),
+			// Cloud Monitoring needs the start time to be before the time
+			StartTimeUnixNano: uint64(now.Add(-time.Second).UnixNano()),
+			TimeUnixNano:      uint64(now.UnixNano()),
+			Value:             &metricspb.NumberDataPoint_AsInt{AsInt: 1},
+		})
+	}
+	res, err := client.Export(ctx, &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: resource(),
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Scope: scope(),
+				Metrics: []*metricspb.Metric{{
+					Name: MetricName,
+					Unit: "1",
+					Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						DataPoints:             points,
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+						IsMonotonic:            true,
+					}},
+				}},
+			}},
+		}},
+	}, grpc.WaitForReady(true))
+	if err != nil {
+		return err
+	}
+	if rejected := res.GetPartialSuccess().GetRejectedDataPoints(); rejected > 0 {
+		return fmt.Errorf("%v points rejected: %v", rejected, res.GetPartialSuccess().GetErrorMessage())
+	}
+	return nil
+}
+
+func sendLogs(ctx context.Context, client collogspb.LogsServiceClient, cfg Config) error {
+	for start := 0; start < cfg.Logs; start += cfg.BatchSize {
+		end := min(start+cfg.BatchSize, cfg.Logs)
+		now := uint64(time.Now().UnixNano())
+		records := make([]*logspb.LogRecord, 0, end-start)
+		for i := start; i < end; i++ {
+			records = append(records, &logspb.LogRecord{
+				TimeUnixNano:         now,
+				ObservedTimeUnixNano: now,
+				SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+				SeverityText:         "INFO",
+				Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprintf("loadgen log %v", i)}},
+				Attributes:           append(attributes(cfg.TestRunID), stringAttribute(LogIndexKey, strconv.Itoa(i))),
+			})
+		}
+		res, err := client.Export(ctx, &collogspb.ExportLogsServiceRequest{
+			ResourceLogs: []*logspb.ResourceLogs{{
+				Resource:  resource(),
+				ScopeLogs: []*logspb.ScopeLogs{{Scope: scope(), LogRecords: records}},
+			}},
+		}, grpc.WaitForReady(true))
+		if err != nil {
+			return err
+		}
+		if rejected := res.GetPartialSuccess().GetRejectedLogRecords(); rejected > 0 {
+			return fmt.Errorf("%v log records rejected: %v", rejected, res.GetPartialSuccess().GetErrorMessage())
+		}
+	}
+	return nil
+}
+
+func resource() *resourcepb.Resource {
+	return &resourcepb.Resource{
+		Attributes: []*commonpb.KeyValue{stringAttribute("service.name", serviceName)},
+	}
+}
+
+func scope() *commonpb.InstrumentationScope {
+	return &commonpb.InstrumentationScope{Name: "github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/loadgen"}
+}
+
+func attributes(testRunID string) []*commonpb.KeyValue {
+	return []*commonpb.KeyValue{stringAttribute(TestRunIDKey, testRunID)}
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func randomID(n int) []byte {
+	id := make([]byte, n)
+	rand.Read(id)
+	return id
+}
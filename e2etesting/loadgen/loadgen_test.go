@@ -0,0 +1,165 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Records what it receives like an OTLP receiver
+type fakeReceiver struct {
+	coltracepb.UnimplementedTraceServiceServer
+	colmetricspb.UnimplementedMetricsServiceServer
+	collogspb.UnimplementedLogsServiceServer
+
+	mu              sync.Mutex
+	traceRequests   []*coltracepb.ExportTraceServiceRequest
+	metricsRequests []*colmetricspb.ExportMetricsServiceRequest
+	logsRequests    []*collogspb.ExportLogsServiceRequest
+	rejectSpans     int64
+}
+
+func (f *fakeReceiver) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.traceRequests = append(f.traceRequests, req)
+	res := &coltracepb.ExportTraceServiceResponse{}
+	if f.rejectSpans > 0 {
+		res.PartialSuccess = &coltracepb.ExportTracePartialSuccess{RejectedSpans: f.rejectSpans, ErrorMessage: "too many spans"}
+	}
+	return res, nil
+}
+
+type fakeMetricsReceiver struct{ *fakeReceiver }
+
+func (f fakeMetricsReceiver) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.metricsRequests = append(f.metricsRequests, req)
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+type fakeLogsReceiver struct{ *fakeReceiver }
+
+func (f fakeLogsReceiver) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logsRequests = append(f.logsRequests, req)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func startFakeReceiver(t *testing.T) (*fakeReceiver, *grpc.ClientConn) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	f := &fakeReceiver{}
+	srv := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(srv, f)
+	colmetricspb.RegisterMetricsServiceServer(srv, fakeMetricsReceiver{f})
+	collogspb.RegisterLogsServiceServer(srv, fakeLogsReceiver{f})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return f, conn
+}
+
+func attribute(attrs []*commonpb.KeyValue, key string) string {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.GetValue().GetStringValue()
+		}
+	}
+	return ""
+}
+
+func TestRun(t *testing.T) {
+	f, conn := startFakeReceiver(t)
+
+	err := Run(context.Background(), conn, Config{
+		TestRunID:    "abc123",
+		Spans:        25,
+		MetricSeries: 7,
+		Logs:         12,
+		BatchSize:    10,
+	})
+	require.NoError(t, err)
+
+	// Spans in batches, grouped into traces of SpansPerTrace
+	require.Len(t, f.traceRequests, 3)
+	traceSpans := map[string]int{}
+	spans := 0
+	for _, req := range f.traceRequests {
+		for _, span := range req.ResourceSpans[0].ScopeSpans[0].Spans {
+			spans++
+			traceSpans[string(span.TraceId)]++
+			assert.Equal(t, SpanName, span.Name)
+			assert.Equal(t, "abc123", attribute(span.Attributes, TestRunIDKey))
+		}
+	}
+	assert.Equal(t, 25, spans)
+	assert.Len(t, traceSpans, 3)
+
+	// A point per series in one request
+	require.Len(t, f.metricsRequests, 1)
+	metric := f.metricsRequests[0].ResourceMetrics[0].ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, MetricName, metric.Name)
+	series := map[string]bool{}
+	for _, point := range metric.GetSum().DataPoints {
+		series[attribute(point.Attributes, SeriesKey)] = true
+		assert.Equal(t, "abc123", attribute(point.Attributes, TestRunIDKey))
+		assert.Less(t, point.StartTimeUnixNano, point.TimeUnixNano)
+	}
+	assert.Len(t, series, 7)
+
+	require.Len(t, f.logsRequests, 2)
+	indexes := map[string]bool{}
+	for _, req := range f.logsRequests {
+		for _, record := range req.ResourceLogs[0].ScopeLogs[0].LogRecords {
+			indexes[attribute(record.Attributes, LogIndexKey)] = true
+			assert.Equal(t, "abc123", attribute(record.Attributes, TestRunIDKey))
+		}
+	}
+	assert.Len(t, indexes, 12)
+}
+
+func TestRunRejected(t *testing.T) {
+	f, conn := startFakeReceiver(t)
+	f.rejectSpans = 2
+
+	err := Run(context.Background(), conn, Config{TestRunID: "abc123", Spans: 5, BatchSize: 10})
+	assert.ErrorContains(t, err, "2 spans rejected: too many spans")
+	assert.Empty(t, f.metricsRequests)
+}
+
+func TestRunInvalidBatchSize(t *testing.T) {
+	_, conn := startFakeReceiver(t)
+
+	err := Run(context.Background(), conn, Config{TestRunID: "abc123", Spans: 5})
+	assert.ErrorContains(t, err, "batch size must be positive")
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2etesting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+)
+
+type MatrixCmd struct {
+	Platforms []string `arg:"--platform,separate,required" help:"A test server subcommand and its flags to deploy and test, e.g. --platform='gke --image=IMAGE'. Repeat for each platform"`
+}
+
+// Short names for the platforms in test run IDs, which end up in resource
+// names with length limits, e.g. 49 characters for Cloud Run services
+var platformIDSuffixes = map[string]string{
+	"local":                "local",
+	"gce":                  "gce",
+	"gke":                  "gke",
+	"cloud-run":            "run",
+	"cloud-functions-gen2": "gcf",
+	"gae":                  "gae",
+	"gae-standard":         "gaes",
+}
+
+// PlatformArgs parses each --platform into the Args to deploy and test that
+// platform with. They share the rest of parent's options, and each gets a test
+// run ID of its own derived from parent's, e.g. abc123-gke.
+func (c *MatrixCmd) PlatformArgs(parent *Args) ([]*Args, error) {
+	var platforms []*Args
+	seen := map[string]int{}
+	for _, spec := range c.Platforms {
+		parsed := &Args{}
+		p, err := arg.NewParser(arg.Config{Program: "--platform"}, parsed)
+		if err != nil {
+			return nil, err
+		}
+		// Values can't contain spaces, which none of the subcommands' flags need
+		fields := append(strings.Fields(spec), "--project-id", parent.ProjectID)
+		if err := p.Parse(fields); err != nil {
+			return nil, fmt.Errorf("invalid --platform %q: %w", spec, err)
+		}
+		platform := parsed.Platform()
+		if platform == "" {
+			return nil, fmt.Errorf("invalid --platform %q: must be a test server subcommand", spec)
+		}
+
+		// Only take the subcommand from parsed
+		args := *parent
+		args.Matrix = nil
+		args.Local = parsed.Local
+		args.Gce = parsed.Gce
+		args.Gke = parsed.Gke
+		args.CloudRun = parsed.CloudRun
+		args.CloudFunctionsGen2 = parsed.CloudFunctionsGen2
+		args.Gae = parsed.Gae
+		args.GaeStandard = parsed.GaeStandard
+		args.subcommand = platform
+
+		suffix := platformIDSuffixes[platform]
+		// e.g. gae with two different runtimes
+		if n := seen[platform]; n > 0 {
+			suffix = fmt.Sprintf("%v%v", suffix, n+1)
+		}
+		seen[platform]++
+		args.TestRunID = fmt.Sprintf("%v-%v", parent.TestRunID, suffix)
+		platforms = append(platforms, &args)
+	}
+	return platforms, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2etesting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlatformArgs(t *testing.T) {
+	parent := &Args{
+		CmdWithProjectId:   CmdWithProjectId{ProjectID: "project"},
+		TestRunID:          "abc123",
+		HealthCheckTimeout: 5 * time.Minute,
+		Matrix: &MatrixCmd{Platforms: []string{
+			"gke --image=server:latest",
+			"cloud-run --image=server:latest",
+			"gae --image=server:latest --runtime=go",
+			"gae --image=server:latest --runtime=python",
+			"cloud-functions-gen2 --runtime=go123 --entrypoint=Handle --functionsource=/source.zip",
+		}},
+	}
+
+	platforms, err := parent.Matrix.PlatformArgs(parent)
+	require.NoError(t, err)
+	require.Len(t, platforms, 5)
+
+	var names, ids []string
+	for _, platform := range platforms {
+		names = append(names, platform.Platform())
+		ids = append(ids, platform.TestRunID)
+		assert.Nil(t, platform.Matrix)
+		assert.Equal(t, "project", platform.ProjectID)
+		assert.Equal(t, 5*time.Minute, platform.HealthCheckTimeout)
+	}
+	assert.Equal(t, []string{"gke", "cloud-run", "gae", "gae", "cloud-functions-gen2"}, names)
+	assert.Equal(t, []string{"abc123-gke", "abc123-run", "abc123-gae", "abc123-gae2", "abc123-gcf"}, ids)
+	assert.Equal(t, "server:latest", platforms[0].Gke.Image)
+	assert.Nil(t, platforms[0].Gce)
+	assert.Equal(t, "python", platforms[3].Gae.Runtime)
+	assert.Equal(t, "/source.zip", platforms[4].CloudFunctionsGen2.FunctionSource)
+	assert.Equal(t, report.Platform{Name: "gae", Image: "server:latest", TestRunID: "abc123-gae2"}, platforms[3].ReportPlatform())
+	assert.Equal(t, "/source.zip", platforms[4].ReportPlatform().Image)
+
+	// The parent is left alone
+	assert.Equal(t, "abc123", parent.TestRunID)
+	assert.NotNil(t, parent.Matrix)
+}
+
+func TestPlatformArgsErrors(t *testing.T) {
+	for _, spec := range []string{
+		"gke",
+		"gce-collector --image=collector:latest",
+		"matrix --platform=gke",
+		"unknown --image=server:latest",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			parent := &Args{TestRunID: "abc123", Matrix: &MatrixCmd{Platforms: []string{spec}}}
+			_, err := parent.Matrix.PlatformArgs(parent)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JUnit timestamps have no time zone, they're in UTC
+const junitTimestamp = "2006-01-02T15:04:05"
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// JUnit renders the report as JUnit XML, with a test suite per platform.
+func (r *Report) JUnit() ([]byte, error) {
+	var suites []junitTestSuite
+	// Only tests whose parent isn't in the suite count towards its time,
+	// subtests are included in their parent's time
+	var seconds []float64
+	var names []map[string]bool
+	suiteIndex := map[Platform]int{}
+	for _, result := range r.Results {
+		i, ok := suiteIndex[result.Platform]
+		if !ok {
+			i = len(suites)
+			suiteIndex[result.Platform] = i
+			seconds = append(seconds, 0)
+			names = append(names, map[string]bool{})
+			suites = append(suites, junitTestSuite{
+				Name:      result.Platform.Name,
+				Timestamp: r.StartTime.UTC().Format(junitTimestamp),
+				Properties: []junitProperty{
+					{Name: "image", Value: result.Image},
+					{Name: "test_run_id", Value: result.TestRunID},
+				},
+			})
+		}
+		suite := &suites[i]
+
+		testCase := junitTestCase{
+			Name:      result.Name,
+			Classname: result.Platform.Name,
+			Time:      fmt.Sprintf("%.3f", result.DurationSeconds),
+		}
+		switch result.Status {
+		case Fail:
+			suite.Failures++
+			testCase.Failure = &junitMessage{Message: firstLine(result.Message), Body: result.Message}
+		case Skip:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: firstLine(result.Message)}
+		}
+		if len(result.Scenarios) > 0 || len(result.TraceIDs) > 0 {
+			testCase.SystemOut = fmt.Sprintf(
+				"scenarios: %v\ntrace_ids: %v\n",
+				strings.Join(result.Scenarios, ", "),
+				strings.Join(result.TraceIDs, ", "),
+			)
+		}
+		if parent := parentName(result.Name); !names[i][parent] {
+			seconds[i] += result.DurationSeconds
+		}
+		names[i][result.Name] = true
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	for i := range suites {
+		suites[i].Time = fmt.Sprintf("%.3f", seconds[i])
+	}
+
+	out, err := xml.MarshalIndent(junitTestSuites{Suites: suites}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// ParseJUnit reads a report written to JUnitFile, or JUnit XML from another
+// tool with the same layout of a test suite per platform. Its Platform is the
+// first test suite's.
+func ParseJUnit(data []byte) (*Report, error) {
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		return nil, err
+	}
+	r := &Report{Results: []Result{}}
+	for i, suite := range suites.Suites {
+		platform := Platform{Name: suite.Name}
+		for _, property := range suite.Properties {
+			switch property.Name {
+			case "image":
+				platform.Image = property.Value
+			case "test_run_id":
+				platform.TestRunID = property.Value
+			}
+		}
+		if i == 0 {
+			r.Platform = platform
+			r.StartTime, _ = time.Parse(junitTimestamp, suite.Timestamp)
+		}
+
+		for _, testCase := range suite.Cases {
+			result := Result{Name: testCase.Name, Platform: platform, Status: Pass}
+			result.DurationSeconds, _ = strconv.ParseFloat(testCase.Time, 64)
+			switch {
+			case testCase.Failure != nil:
+				result.Status = Fail
+				result.Message = testCase.Failure.Body
+				if result.Message == "" {
+					result.Message = testCase.Failure.Message
+				}
+			case testCase.Skipped != nil:
+				result.Status = Skip
+				result.Message = testCase.Skipped.Message
+			}
+			for _, line := range strings.Split(testCase.SystemOut, "\n") {
+				if scenarios, ok := strings.CutPrefix(line, "scenarios: "); ok {
+					result.Scenarios = splitList(scenarios)
+				} else if traceIDs, ok := strings.CutPrefix(line, "trace_ids: "); ok {
+					result.TraceIDs = splitList(traceIDs)
+				}
+			}
+			r.Results = append(r.Results, result)
+		}
+	}
+	return r, nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ", ")
+}
+
+// e.g. TestMatrix/gke for TestMatrix/gke/TestBasicTrace, or "" for top level
+// tests
+func parentName(name string) string {
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return ""
+	}
+	return name[:i]
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,219 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	// e.g. "=== RUN   TestBasicTrace", or NAME when output switches back to a
+	// parent test
+	startRe = regexp.MustCompile(`^=== (?:RUN|CONT|NAME|PAUSE)\s+(\S+)$`)
+	// e.g. "    --- FAIL: TestBasicTrace/sub (1.23s)"
+	endRe = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([\d.]+)s\)$`)
+)
+
+// Recorder collects the results of the tests from their -test.v output, see
+// Run. Its methods for adding to a test's result are safe to call on a nil
+// Recorder, which does nothing, so tests don't need to check whether a report
+// was asked for.
+type Recorder struct {
+	platform Platform
+	start    time.Time
+
+	mu      sync.Mutex
+	partial []byte
+	// The test the output currently belongs to
+	current string
+	order   []string
+	results map[string]*Result
+	output  map[string][]string
+	// Added by tests, kept apart from results since the output is parsed
+	// asynchronously and may not have caught up with the test yet
+	platforms map[string]Platform
+	scenarios map[string][]string
+	traceIDs  map[string][]string
+}
+
+// NewRecorder returns a Recorder whose results run on platform unless a test
+// sets another one.
+func NewRecorder(platform Platform) *Recorder {
+	return &Recorder{
+		platform:  platform,
+		start:     time.Now(),
+		results:   map[string]*Result{},
+		output:    map[string][]string{},
+		platforms: map[string]Platform{},
+		scenarios: map[string][]string{},
+		traceIDs:  map[string][]string{},
+	}
+}
+
+// SetPlatform sets the platform of the named test and its subtests.
+func (r *Recorder) SetPlatform(testName string, platform Platform) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.platforms[testName] = platform
+}
+
+// AddScenario records that the named test sent a scenario.
+func (r *Recorder) AddScenario(testName string, scenario string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.scenarios[testName], scenario) {
+		r.scenarios[testName] = append(r.scenarios[testName], scenario)
+	}
+}
+
+// AddTraceID records a trace the named test checked.
+func (r *Recorder) AddTraceID(testName string, traceID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.traceIDs[testName], traceID) {
+		r.traceIDs[testName] = append(r.traceIDs[testName], traceID)
+	}
+}
+
+// Write parses -test.v output.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.partial = append(r.partial, p...)
+	for {
+		i := bytes.IndexByte(r.partial, '\n')
+		if i < 0 {
+			break
+		}
+		r.parseLine(string(r.partial[:i]))
+		r.partial = r.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+func (r *Recorder) parseLine(line string) {
+	if match := startRe.FindStringSubmatch(line); match != nil {
+		r.current = match[1]
+		if _, ok := r.results[r.current]; !ok {
+			r.results[r.current] = &Result{Name: r.current}
+			r.order = append(r.order, r.current)
+		}
+		return
+	}
+	if match := endRe.FindStringSubmatch(line); match != nil {
+		result, ok := r.results[match[2]]
+		if !ok {
+			result = &Result{Name: match[2]}
+			r.results[match[2]] = result
+			r.order = append(r.order, match[2])
+		}
+		result.Status = Status(match[1])
+		result.DurationSeconds, _ = strconv.ParseFloat(match[3], 64)
+		return
+	}
+	// Test output is indented, anything else is e.g. the final PASS/FAIL line
+	if r.current != "" && strings.HasPrefix(line, "    ") {
+		r.output[r.current] = append(r.output[r.current], line)
+	}
+}
+
+// Report returns the results of all of the tests which finished so far.
+func (r *Recorder) Report() *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := &Report{Platform: r.platform, StartTime: r.start, Results: []Result{}}
+	for _, name := range r.order {
+		result := *r.results[name]
+		if result.Status == "" {
+			// Never finished, e.g. the test binary panicked
+			continue
+		}
+		result.Platform = r.platformFor(name)
+		result.Scenarios = r.scenarios[name]
+		result.TraceIDs = r.traceIDs[name]
+		if result.Status != Pass {
+			result.Message = dedent(r.output[name])
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// The platform set for the test or its closest parent
+func (r *Recorder) platformFor(name string) Platform {
+	for ; name != ""; name = parentName(name) {
+		if platform, ok := r.platforms[name]; ok {
+			return platform
+		}
+	}
+	return r.platform
+}
+
+func dedent(lines []string) string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, "    ")
+	}
+	return strings.Join(out, "\n")
+}
+
+// Run runs the tests with -test.v, passing their output through r as well as
+// writing it to stdout, and returns m.Run's exit code. With a nil r, it just
+// runs the tests.
+func Run(m *testing.M, r *Recorder) (int, error) {
+	if r == nil {
+		return m.Run(), nil
+	}
+	if err := flag.Set("test.v", "true"); err != nil {
+		return 0, err
+	}
+
+	// The testing package writes to whatever os.Stdout is when m.Run starts
+	stdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	copied := make(chan error)
+	go func() {
+		_, err := io.Copy(io.MultiWriter(stdout, r), reader)
+		copied <- err
+	}()
+
+	os.Stdout = writer
+	code := m.Run()
+	os.Stdout = stdout
+	writer.Close()
+	return code, <-copied
+}
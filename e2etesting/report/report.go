@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report writes the results of a test run as JSON and JUnit XML, so
+// they can be read without scraping the go test output.
+//
+// The testing package doesn't expose results to TestMain, so a Recorder parses
+// them out of the -test.v output while passing it through. Tests add what
+// can't be parsed, like the scenarios they sent and trace IDs they got back.
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	JSONFile  = "report.json"
+	JUnitFile = "junit.xml"
+)
+
+type Status string
+
+const (
+	Pass Status = "PASS"
+	Fail Status = "FAIL"
+	Skip Status = "SKIP"
+)
+
+// Platform is where a test ran
+type Platform struct {
+	// The subcommand, e.g. gke or gce-collector
+	Name string `json:"platform"`
+	// The container image, or source zip for platforms deployed from source
+	Image     string `json:"image,omitempty"`
+	TestRunID string `json:"test_run_id"`
+}
+
+type Result struct {
+	// The full test name, e.g. TestComplexTrace/Span_has_label_test_id
+	Name string `json:"name"`
+	Platform
+	// Scenarios the test sent to the test server
+	Scenarios       []string `json:"scenarios,omitempty"`
+	Status          Status   `json:"status"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	TraceIDs        []string `json:"trace_ids,omitempty"`
+	// The test's output for failed and skipped tests, e.g. assertion failures
+	// or the skip reason
+	Message string `json:"message,omitempty"`
+}
+
+type Report struct {
+	// The runner's own platform. Results may have a different one, e.g. with
+	// the matrix subcommand.
+	Platform
+	StartTime time.Time `json:"start_time"`
+	Results   []Result  `json:"results"`
+}
+
+// WriteFiles writes the JSONFile and JUnitFile into dir, creating it if needed.
+func (r *Report) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	jsonOut, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, JSONFile), jsonOut, 0o644); err != nil {
+		return err
+	}
+
+	junitOut, err := r.JUnit()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, JUnitFile), junitOut, 0o644)
+}
+
+// ParseJSON reads a report written to JSONFile.
+func ParseJSON(data []byte) (*Report, error) {
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	gke = Platform{Name: "gke", Image: "server:latest", TestRunID: "abc123-gke"}
+	run = Platform{Name: "cloud-run", Image: "server:latest", TestRunID: "abc123-run"}
+)
+
+// Output of go test -test.v, as written in several chunks
+var verboseOutput = []string{
+	"=== RUN   TestBasicTrace\n",
+	"    trace_test.go:113: Retrying GetTrace(0af7651916cd43dd8448eb211c80319c): not found\n",
+	"--- PASS: TestBasicTrace (2.51s)\n=== RUN   TestComplexTrace\n=== RUN   TestComplexTrace/Span_has_label_test_id\n",
+	"    trace_test.go:215: \n        \tError Trace:\ttrace_test.go:215\n        \tError:      \tNot equal\n",
+	"=== NAME  TestComplexTrace\n    trace_test.go:230: after subtests\n",
+	"--- FAIL: TestComplexTrace (3.00s)\n    --- FAIL: TestComplexTrace/Span_has_label_test_id (1.25s)\n",
+	"=== RUN   TestBasicPropagator\n    trace_test.go:96: test server does not support this scenario, skipping\n",
+	"--- SKIP: TestBasicPropagator (0.10s)\n",
+	"=== RUN   TestMatrix\n=== RUN   TestMatrix/gke\n=== RUN   TestMatrix/gke/TestBasicTrace\n",
+	"--- PASS: TestMatrix (5.00s)\n    --- PASS: TestMatrix/gke (3.00s)\n        --- PASS: TestMatrix/gke/TestBasicTrace (3.00s)\n",
+	"=== RUN   TestMatrix/cloud-run\n=== RUN   TestMatrix/cloud-run/TestBasicTrace\n",
+	"    --- PASS: TestMatrix/cloud-run (2.00s)\n        --- PASS: TestMatrix/cloud-run/TestBasicTrace (2.00s)\n",
+	"=== RUN   TestUnfinished\n",
+	"FAIL\n",
+}
+
+func newTestRecorder(t *testing.T) *Recorder {
+	r := NewRecorder(Platform{Name: "gce", Image: "server:latest", TestRunID: "abc123"})
+	r.AddScenario("TestBasicTrace", "/basicTrace")
+	r.AddScenario("TestBasicTrace", "/basicTrace")
+	r.AddTraceID("TestBasicTrace", "0af7651916cd43dd8448eb211c80319c")
+	r.AddScenario("TestComplexTrace/Span_has_label_test_id", "/complexTrace")
+	r.SetPlatform("TestMatrix/gke", gke)
+	r.SetPlatform("TestMatrix/cloud-run", run)
+	for _, chunk := range verboseOutput {
+		_, err := r.Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+	return r
+}
+
+func TestRecorderReport(t *testing.T) {
+	rep := newTestRecorder(t).Report()
+
+	assert.Equal(t, "gce", rep.Platform.Name)
+	gce := rep.Platform
+	assert.Equal(t, []Result{
+		{
+			Name:            "TestBasicTrace",
+			Platform:        gce,
+			Scenarios:       []string{"/basicTrace"},
+			Status:          Pass,
+			DurationSeconds: 2.51,
+			TraceIDs:        []string{"0af7651916cd43dd8448eb211c80319c"},
+		},
+		{
+			Name:            "TestComplexTrace",
+			Platform:        gce,
+			Status:          Fail,
+			DurationSeconds: 3,
+			Message:         "trace_test.go:230: after subtests",
+		},
+		{
+			Name:            "TestComplexTrace/Span_has_label_test_id",
+			Platform:        gce,
+			Scenarios:       []string{"/complexTrace"},
+			Status:          Fail,
+			DurationSeconds: 1.25,
+			Message:         "trace_test.go:215: \n    \tError Trace:\ttrace_test.go:215\n    \tError:      \tNot equal",
+		},
+		{
+			Name:            "TestBasicPropagator",
+			Platform:        gce,
+			Status:          Skip,
+			DurationSeconds: 0.1,
+			Message:         "trace_test.go:96: test server does not support this scenario, skipping",
+		},
+		{Name: "TestMatrix", Platform: gce, Status: Pass, DurationSeconds: 5},
+		{Name: "TestMatrix/gke", Platform: gke, Status: Pass, DurationSeconds: 3},
+		{Name: "TestMatrix/gke/TestBasicTrace", Platform: gke, Status: Pass, DurationSeconds: 3},
+		{Name: "TestMatrix/cloud-run", Platform: run, Status: Pass, DurationSeconds: 2},
+		{Name: "TestMatrix/cloud-run/TestBasicTrace", Platform: run, Status: Pass, DurationSeconds: 2},
+	}, rep.Results)
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	assert.NotPanics(t, func() {
+		r.AddScenario("TestBasicTrace", "/basicTrace")
+		r.AddTraceID("TestBasicTrace", "0af7651916cd43dd8448eb211c80319c")
+		r.SetPlatform("TestMatrix/gke", gke)
+	})
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "report")
+	require.NoError(t, newTestRecorder(t).Report().WriteFiles(dir))
+
+	jsonOut, err := os.ReadFile(filepath.Join(dir, JSONFile))
+	require.NoError(t, err)
+	var fromJSON map[string]any
+	require.NoError(t, json.Unmarshal(jsonOut, &fromJSON))
+	assert.Equal(t, "gce", fromJSON["platform"])
+	first := fromJSON["results"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"name":             "TestBasicTrace",
+		"platform":         "gce",
+		"image":            "server:latest",
+		"test_run_id":      "abc123",
+		"scenarios":        []any{"/basicTrace"},
+		"status":           "PASS",
+		"duration_seconds": 2.51,
+		"trace_ids":        []any{"0af7651916cd43dd8448eb211c80319c"},
+	}, first)
+
+	junitOut, err := os.ReadFile(filepath.Join(dir, JUnitFile))
+	require.NoError(t, err)
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(junitOut, &suites))
+	require.Len(t, suites.Suites, 3)
+
+	suite := suites.Suites[0]
+	assert.Equal(t, "gce", suite.Name)
+	assert.Equal(t, 5, suite.Tests)
+	assert.Equal(t, 2, suite.Failures)
+	assert.Equal(t, 1, suite.Skipped)
+	// Subtests are already part of their parent's time
+	assert.Equal(t, "10.610", suite.Time)
+	assert.Contains(t, suite.Properties, junitProperty{Name: "test_run_id", Value: "abc123"})
+	assert.Equal(t, "scenarios: /basicTrace\ntrace_ids: 0af7651916cd43dd8448eb211c80319c\n", suite.Cases[0].SystemOut)
+	require.NotNil(t, suite.Cases[2].Failure)
+	assert.Equal(t, "trace_test.go:215:", suite.Cases[2].Failure.Message)
+	require.NotNil(t, suite.Cases[3].Skipped)
+	assert.True(t, strings.HasSuffix(suite.Cases[3].Skipped.Message, "skipping"))
+
+	// The matrix platforms' suites only have their own tests, with their
+	// parent's time
+	assert.Equal(t, "gke", suites.Suites[1].Name)
+	assert.Equal(t, 2, suites.Suites[1].Tests)
+	assert.Equal(t, "3.000", suites.Suites[1].Time)
+	assert.Contains(t, suites.Suites[2].Properties, junitProperty{Name: "test_run_id", Value: "abc123-run"})
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	rep := newTestRecorder(t).Report()
+	// JUnit only has second precision
+	rep.StartTime = rep.StartTime.UTC().Truncate(time.Second)
+
+	jsonOut, err := json.Marshal(rep)
+	require.NoError(t, err)
+	fromJSON, err := ParseJSON(jsonOut)
+	require.NoError(t, err)
+	assert.Equal(t, rep, fromJSON)
+
+	junitOut, err := rep.JUnit()
+	require.NoError(t, err)
+	fromJUnit, err := ParseJUnit(junitOut)
+	require.NoError(t, err)
+	assert.Equal(t, rep.Platform, fromJUnit.Platform)
+	assert.Equal(t, rep.StartTime, fromJUnit.StartTime)
+	// The suites group the results by platform
+	require.Len(t, fromJUnit.Results, len(rep.Results))
+	for _, result := range rep.Results {
+		assert.Contains(t, fromJUnit.Results, result)
+	}
+}
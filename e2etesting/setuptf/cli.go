@@ -0,0 +1,191 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setuptf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TerraformBinary = "terraform"
+	TofuBinary      = "tofu"
+)
+
+// How long an interrupted command gets to exit before it is killed
+const interruptWaitDelay = 5 * time.Minute
+
+// The IaC CLI to apply the tf/ modules with, e.g. terraform or tofu, or a path
+// to either. If empty, terraform is used when it's on the PATH and tofu
+// otherwise. Set from --iac-binary.
+var IacBinary string
+
+// cli runs terraform compatible commands with the detected binary and version
+type cli struct {
+	binary  string
+	tofu    bool
+	version cliVersion
+	// TF_DATA_DIR of the commands relative to their directory, if not the
+	// default .terraform
+	dataDir string
+}
+
+type cliVersion struct {
+	major, minor, patch int
+}
+
+func (v cliVersion) String() string {
+	return fmt.Sprintf("%v.%v.%v", v.major, v.minor, v.patch)
+}
+
+func (v cliVersion) atLeast(major, minor int) bool {
+	return v.major > major || (v.major == major && v.minor >= minor)
+}
+
+// Resolves IacBinary and asks it for its version.
+func detectCLI(ctx context.Context, logger *log.Logger) (*cli, error) {
+	binary := IacBinary
+	if binary == "" {
+		for _, candidate := range []string{TerraformBinary, TofuBinary} {
+			if _, err := exec.LookPath(candidate); err == nil {
+				binary = candidate
+				break
+			}
+		}
+		if binary == "" {
+			return nil, fmt.Errorf("neither %v nor %v found on the PATH, install one or pass --iac-binary", TerraformBinary, TofuBinary)
+		}
+	}
+
+	out, err := exec.CommandContext(ctx, binary, "version", "-json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the version of %v: %w", binary, err)
+	}
+	version, err := parseVersionJSON(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the version of %v: %w", binary, err)
+	}
+
+	c := &cli{
+		binary:  binary,
+		tofu:    strings.HasPrefix(filepath.Base(binary), TofuBinary),
+		version: version,
+	}
+	logger.Printf("Using %v version %v\n", c.binary, c.version)
+	return c, nil
+}
+
+// Parses the output of `version -json`. OpenTofu keeps the terraform_version
+// key for compatibility, but newer versions may only set tofu_version.
+func parseVersionJSON(out []byte) (cliVersion, error) {
+	versionOutput := struct {
+		TerraformVersion string `json:"terraform_version"`
+		TofuVersion      string `json:"tofu_version"`
+	}{}
+	if err := json.Unmarshal(out, &versionOutput); err != nil {
+		return cliVersion{}, err
+	}
+	raw := versionOutput.TofuVersion
+	if raw == "" {
+		raw = versionOutput.TerraformVersion
+	}
+	if raw == "" {
+		return cliVersion{}, fmt.Errorf("no version in %q", out)
+	}
+
+	// Drop any pre-release suffix, e.g. 1.9.0-beta1
+	raw, _, _ = strings.Cut(strings.TrimPrefix(raw, "v"), "-")
+	parts := strings.SplitN(raw, ".", 3)
+	var nums [3]int
+	for i, part := range parts {
+		num, err := strconv.Atoi(part)
+		if err != nil {
+			return cliVersion{}, fmt.Errorf("invalid version %q: %w", raw, err)
+		}
+		nums[i] = num
+	}
+	return cliVersion{major: nums[0], minor: nums[1], patch: nums[2]}, nil
+}
+
+// When ctx is canceled, the command is interrupted rather than killed, so it
+// can release its state lock and write out what it already created.
+func (c *cli) command(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, c.binary, args...)
+	cmd.Dir = dir
+	if c.dataDir != "" {
+		cmd.Env = append(os.Environ(), "TF_DATA_DIR="+c.dataDir)
+	}
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = interruptWaitDelay
+	return cmd
+}
+
+func (c *cli) initCommand(ctx context.Context, dir string, projectID string) *exec.Cmd {
+	return c.command(
+		ctx,
+		dir,
+		"init",
+		"-input=false",
+		fmt.Sprintf("-backend-config=bucket=%v", StateBucket(projectID)),
+	)
+}
+
+// `workspace select -or-create` was added in terraform 1.4, and has been in
+// OpenTofu since its first release
+func (c *cli) supportsSelectOrCreate() bool {
+	return c.tofu || c.version.atLeast(1, 4)
+}
+
+// Runs plan and reports whether applying would change anything, e.g. because
+// an input variable like the image changed
+func (c *cli) planHasChanges(ctx context.Context, dir string, tfVarArgs []string, logger *log.Logger) (bool, error) {
+	cmd := c.command(ctx, dir, "plan", "-input=false", "-detailed-exitcode")
+	cmd.Args = append(cmd.Args, tfVarArgs...)
+	err := runWithOutput(cmd, logger)
+	// -detailed-exitcode exits with 2 if there are changes
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 2:
+		return true, nil
+	default:
+		return false, err
+	}
+}
+
+func (c *cli) selectOrCreateWorkspace(ctx context.Context, dir string, workspace string, logger *log.Logger) error {
+	if c.supportsSelectOrCreate() {
+		return runWithOutput(c.command(ctx, dir, "workspace", "select", "-or-create", workspace), logger)
+	}
+
+	// Create new workspace
+	if err := runWithOutput(c.command(ctx, dir, "workspace", "new", workspace), logger); err != nil {
+		// try to switch to workspace if it already exists
+		return runWithOutput(c.command(ctx, dir, "workspace", "select", workspace), logger)
+	}
+	return nil
+}
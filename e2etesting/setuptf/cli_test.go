@@ -0,0 +1,268 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setuptf
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersionJSON(t *testing.T) {
+	for _, tc := range []struct {
+		out    string
+		expect cliVersion
+	}{
+		{out: `{"terraform_version": "1.13.3", "platform": "linux_amd64"}`, expect: cliVersion{1, 13, 3}},
+		{out: `{"terraform_version": "1.3.0"}`, expect: cliVersion{1, 3, 0}},
+		{out: `{"terraform_version": "1.6.0", "tofu_version": "1.8.2"}`, expect: cliVersion{1, 8, 2}},
+		{out: `{"tofu_version": "v1.9.0-beta1"}`, expect: cliVersion{1, 9, 0}},
+	} {
+		t.Run(tc.out, func(t *testing.T) {
+			version, err := parseVersionJSON([]byte(tc.out))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, version)
+		})
+	}
+
+	_, err := parseVersionJSON([]byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestSupportsSelectOrCreate(t *testing.T) {
+	assert.False(t, (&cli{version: cliVersion{1, 3, 9}}).supportsSelectOrCreate())
+	assert.True(t, (&cli{version: cliVersion{1, 4, 0}}).supportsSelectOrCreate())
+	assert.True(t, (&cli{version: cliVersion{2, 0, 0}}).supportsSelectOrCreate())
+	assert.True(t, (&cli{tofu: true, version: cliVersion{1, 6, 0}}).supportsSelectOrCreate())
+}
+
+// Writes a fake IaC binary which logs its arguments and prints canned output
+func writeFakeBinary(t *testing.T, name string, versionJSON string) (string, string) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "args.log")
+	script := `#!/bin/sh
+echo "$*" >> ` + logPath + `
+echo "${TF_DATA_DIR:-default}" >> ` + logPath + `.datadir
+case "$1" in
+version) echo '` + versionJSON + `' ;;
+output) echo '{"pubsub_info": {"value": {"request_topic": {"topic_name": "request"}}}}' ;;
+plan) exit ${FAKE_PLAN_EXITCODE:-0} ;;
+init)
+	mkdir .fake-init 2>/dev/null || echo "$PWD" >> ` + logPath + `.overlap
+	sleep 0.1
+	rmdir .fake-init ;;
+esac
+`
+	binary := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o755))
+	return binary, logPath
+}
+
+func readLines(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestTerraformApply(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		binaryName      string
+		versionJSON     string
+		expectWorkspace []string
+	}{
+		{
+			name:            "terraform",
+			binaryName:      TerraformBinary,
+			versionJSON:     `{"terraform_version": "1.13.3"}`,
+			expectWorkspace: []string{"workspace select -or-create abc123"},
+		},
+		{
+			name:            "old terraform",
+			binaryName:      TerraformBinary,
+			versionJSON:     `{"terraform_version": "1.3.0"}`,
+			expectWorkspace: []string{"workspace new abc123"},
+		},
+		{
+			name:            "tofu",
+			binaryName:      TofuBinary,
+			versionJSON:     `{"terraform_version": "1.8.2"}`,
+			expectWorkspace: []string{"workspace select -or-create abc123"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			binary, logPath := writeFakeBinary(t, tc.binaryName, tc.versionJSON)
+			IacBinary = binary
+			t.Cleanup(func() { IacBinary = "" })
+
+			outputs, destroy, err := Terraform{}.Apply(context.Background(), ApplyRequest{
+				ProjectID: "project",
+				TestRunID: "abc123",
+				Dir:       t.TempDir(),
+			}, log.New(io.Discard, "", 0))
+			require.NoError(t, err)
+			pubsubInfo, err := outputs.PubsubInfo()
+			require.NoError(t, err)
+			assert.Equal(t, "request", pubsubInfo.RequestTopic.TopicName)
+
+			expect := []string{
+				"version -json",
+				"init -input=false -backend-config=bucket=project-e2e-tfstate -var=project_id=project",
+			}
+			expect = append(expect, tc.expectWorkspace...)
+			expect = append(expect,
+				"apply -input=false -auto-approve -var=project_id=project",
+				"output -json",
+			)
+			assert.Equal(t, expect, readLines(t, logPath))
+
+			destroy()
+			assert.Equal(t, []string{
+				"destroy -input=false -auto-approve -var=project_id=project",
+				"workspace select default",
+				"workspace delete abc123",
+			}, readLines(t, logPath)[len(expect):])
+
+			// All but detecting the version run in the test run's data dir
+			dataDirs := readLines(t, logPath+".datadir")
+			assert.Equal(t, "default", dataDirs[0])
+			for _, dataDir := range dataDirs[1:] {
+				assert.Equal(t, filepath.Join(".terraform", "runs", "abc123"), dataDir)
+			}
+		})
+	}
+}
+
+// Test runs of the same config directory share its lock file and the plugin
+// cache, so their init mustn't overlap
+func TestTerraformApplyConcurrentInit(t *testing.T) {
+	binary, logPath := writeFakeBinary(t, TerraformBinary, `{"terraform_version": "1.13.3"}`)
+	IacBinary = binary
+	t.Cleanup(func() { IacBinary = "" })
+
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for _, testRunID := range []string{"abc123", "abc123-2", "abc123-3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := Terraform{}.Apply(context.Background(), ApplyRequest{
+				ProjectID: "project",
+				TestRunID: testRunID,
+				Dir:       dir,
+			}, log.New(io.Discard, "", 0))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.NoFileExists(t, logPath+".overlap")
+}
+
+func TestTerraformApplyReuse(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		planExit    string
+		expectApply bool
+	}{
+		{name: "unchanged", planExit: "0"},
+		{name: "changed", planExit: "2", expectApply: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			binary, logPath := writeFakeBinary(t, TerraformBinary, `{"terraform_version": "1.13.3"}`)
+			IacBinary = binary
+			t.Cleanup(func() { IacBinary = "" })
+			t.Setenv("FAKE_PLAN_EXITCODE", tc.planExit)
+
+			_, _, err := Terraform{}.Apply(context.Background(), ApplyRequest{
+				ProjectID: "project",
+				TestRunID: "abc123",
+				Dir:       t.TempDir(),
+				Reuse:     true,
+			}, log.New(io.Discard, "", 0))
+			require.NoError(t, err)
+
+			expect := []string{
+				"version -json",
+				"init -input=false -backend-config=bucket=project-e2e-tfstate -var=project_id=project",
+				"workspace select abc123",
+				"plan -input=false -detailed-exitcode -var=project_id=project",
+			}
+			if tc.expectApply {
+				expect = append(expect, "apply -input=false -auto-approve -var=project_id=project")
+			}
+			expect = append(expect, "output -json")
+			assert.Equal(t, expect, readLines(t, logPath))
+		})
+	}
+
+	t.Run("plan error", func(t *testing.T) {
+		binary, _ := writeFakeBinary(t, TerraformBinary, `{"terraform_version": "1.13.3"}`)
+		IacBinary = binary
+		t.Cleanup(func() { IacBinary = "" })
+		t.Setenv("FAKE_PLAN_EXITCODE", "1")
+
+		_, _, err := Terraform{}.Apply(context.Background(), ApplyRequest{
+			ProjectID: "project",
+			TestRunID: "abc123",
+			Dir:       t.TempDir(),
+			Reuse:     true,
+		}, log.New(io.Discard, "", 0))
+		assert.Error(t, err)
+	})
+}
+
+func TestDetectCLIFromPath(t *testing.T) {
+	binary, _ := writeFakeBinary(t, TofuBinary, `{"terraform_version": "1.8.2"}`)
+	t.Setenv("PATH", filepath.Dir(binary))
+
+	c, err := detectCLI(context.Background(), log.New(io.Discard, "", 0))
+	require.NoError(t, err)
+	assert.Equal(t, TofuBinary, c.binary)
+	assert.True(t, c.tofu)
+	assert.Equal(t, cliVersion{1, 8, 2}, c.version)
+}
+
+func TestDestroyWorkspace(t *testing.T) {
+	binary, logPath := writeFakeBinary(t, TerraformBinary, `{"terraform_version": "1.13.3"}`)
+	IacBinary = binary
+	t.Cleanup(func() { IacBinary = "" })
+
+	err := DestroyWorkspace(
+		context.Background(),
+		"project",
+		"abc123",
+		t.TempDir(),
+		map[string]string{"image": "placeholder"},
+		log.New(io.Discard, "", 0),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"version -json",
+		"init -input=false -backend-config=bucket=project-e2e-tfstate -var=project_id=project -var=image=placeholder",
+		"workspace select abc123",
+		"destroy -input=false -auto-approve -refresh=false -lock-timeout=10m -var=project_id=project -var=image=placeholder",
+		"workspace select default",
+		"workspace delete abc123",
+	}, readLines(t, logPath))
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakeprovisioner is a setuptf.Provisioner for offline tests of the
+// Setup* functions. It returns canned outputs without creating anything, and
+// records what was applied and destroyed.
+package fakeprovisioner
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
+)
+
+type Provisioner struct {
+	// Returned from every Apply
+	Outputs setuptf.Outputs
+	// If set, returned from every Apply instead of the outputs
+	Err error
+
+	mu        sync.Mutex
+	applied   []setuptf.ApplyRequest
+	destroyed []setuptf.ApplyRequest
+}
+
+var _ setuptf.Provisioner = (*Provisioner)(nil)
+
+// New returns a Provisioner whose applies output the canned pubsub_info.
+func New(pubsubInfo *setuptf.PubsubInfo) *Provisioner {
+	raw, err := json.Marshal(pubsubInfo)
+	if err != nil {
+		// PubsubInfo is plain strings
+		panic(err)
+	}
+	return &Provisioner{Outputs: setuptf.Outputs{setuptf.PubsubInfoOutput: raw}}
+}
+
+// Install makes p the setuptf.DefaultProvisioner until the end of the test.
+func Install(t testing.TB, p *Provisioner) {
+	previous := setuptf.DefaultProvisioner
+	setuptf.DefaultProvisioner = p
+	t.Cleanup(func() { setuptf.DefaultProvisioner = previous })
+}
+
+func (p *Provisioner) Apply(
+	ctx context.Context,
+	req setuptf.ApplyRequest,
+	logger *log.Logger,
+) (setuptf.Outputs, setuptf.Destroy, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.applied = append(p.applied, req)
+	destroy := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.destroyed = append(p.destroyed, req)
+	}
+	if p.Err != nil {
+		return nil, destroy, p.Err
+	}
+	return p.Outputs, destroy, nil
+}
+
+// Applied returns the requests passed to Apply so far.
+func (p *Provisioner) Applied() []setuptf.ApplyRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]setuptf.ApplyRequest(nil), p.applied...)
+}
+
+// Destroyed returns the requests whose Destroy was called so far.
+func (p *Provisioner) Destroyed() []setuptf.ApplyRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]setuptf.ApplyRequest(nil), p.destroyed...)
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setuptf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// The output holding the request and response Pub/Sub topics for the test
+// server
+const PubsubInfoOutput = "pubsub_info"
+
+// ErrNoOutput is returned from Outputs.Decode for outputs the config doesn't
+// declare
+var ErrNoOutput = errors.New("no such output")
+
+// Provisioner creates the resources for a test run from a config directory,
+// e.g. by running terraform.
+type Provisioner interface {
+	// Apply creates the resources and returns the config's outputs. The returned
+	// Destroy is never nil, even on error, and tears down whatever was created.
+	Apply(ctx context.Context, req ApplyRequest, logger *log.Logger) (Outputs, Destroy, error)
+}
+
+type ApplyRequest struct {
+	ProjectID string
+	// Resources for each test run are kept apart, e.g. in a terraform workspace
+	// named after the test run ID
+	TestRunID string
+	// The config directory, e.g. tf/gke
+	Dir string
+	// Input variables besides project_id
+	Vars map[string]string
+	// Attach to the test run's existing resources instead of creating them,
+	// only updating them if the inputs changed
+	Reuse bool
+}
+
+// Destroy tears down the resources created by Provisioner.Apply
+type Destroy func()
+
+// Outputs holds the JSON value of each output of the applied config
+type Outputs map[string]json.RawMessage
+
+// Decode unmarshals the JSON value of the named output into v. It returns an
+// error wrapping ErrNoOutput if the config doesn't have that output.
+func (o Outputs) Decode(name string, v any) error {
+	raw, ok := o[name]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrNoOutput, name)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("failed to decode output %v: %w", name, err)
+	}
+	return nil
+}
+
+// DecodeAll unmarshals all of the outputs into v, a pointer to a struct whose
+// json tags name the outputs, as if the outputs were one JSON object. Outputs
+// without a matching field are ignored, and fields without an output are left
+// as is.
+func (o Outputs) DecodeAll(v any) error {
+	raw, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("failed to decode outputs: %w", err)
+	}
+	return nil
+}
+
+// PubsubInfo returns the pubsub_info output, or an empty PubsubInfo if the
+// config doesn't have one.
+func (o Outputs) PubsubInfo() (*PubsubInfo, error) {
+	pubsubInfo := &PubsubInfo{}
+	if err := o.Decode(PubsubInfoOutput, pubsubInfo); err != nil && !errors.Is(err, ErrNoOutput) {
+		return nil, err
+	}
+	return pubsubInfo, nil
+}
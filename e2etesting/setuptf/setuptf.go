@@ -16,11 +16,9 @@ package setuptf
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 )
 
 const (
@@ -28,14 +26,21 @@ const (
 	tfPersistentCollectorDir                  = "tf/persistent-collector"
 	Push                     SubscriptionMode = "push"
 	Pull                     SubscriptionMode = "pull"
+	// HTTP tells the test server to serve scenario requests directly on its
+	// PORT instead of subscribing to the request topic
+	HTTP SubscriptionMode = "http"
 )
 
 type SubscriptionMode string
 
-type tfOutput struct {
-	PubsubInfoWrapper struct {
-		Value PubsubInfo `json:"value"`
-	} `json:"pubsub_info"`
+// The prefix of the terraform state objects in the StateBucket, matching the
+// gcs backend in tf/common/main-common.tf. Each workspace's state is in
+// <prefix>/<workspace>.tfstate. All of the tf/ config directories share it.
+const StatePrefix = "terraform/state"
+
+// StateBucket returns the GCS bucket holding the terraform state
+func StateBucket(projectID string) string {
+	return fmt.Sprintf("%v-e2e-tfstate", projectID)
 }
 
 type TopicInfo struct {
@@ -48,37 +53,24 @@ type PubsubInfo struct {
 	ResponseTopic TopicInfo `json:"response_topic"`
 }
 
-func runWithOutput(cmd *exec.Cmd, logger *log.Logger) error {
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	logger.Printf("Running command: %v\n", cmd)
-	if err := cmd.Run(); err != nil {
-		logger.Println(err)
-		return err
-	}
-	return nil
-}
+// Set from --keep-environment, skips destroying the resources on cleanup so
+// a later run can reuse them
+var KeepEnvironment bool
 
-func initCommand(ctx context.Context, projectID string) *exec.Cmd {
-	return exec.CommandContext(
-		ctx,
-		"terraform",
-		"init",
-		"-input=false",
-		fmt.Sprintf("-backend-config=bucket=%v-e2e-tfstate", projectID),
-	)
-}
+// Set from --reuse-test-run-id, attaches to the resources of an earlier run
+// with the same test run ID, see ApplyRequest.Reuse
+var ReuseWorkspace bool
 
-// Runs the sequence of terraform commands most environemnts need, returns the
-// output bytes of `terraform output -json` and a cleanup function to teardown
-// the created resources.
-//
-// 1. Run terraform init
-// 2. Create a new terraform workspace for the test run ID
-// 3. Run terraform apply
-// 4. Get output results from terraform output
+// The Provisioner SetupTf applies with. Tests can swap in a fake, see package
+// fakeprovisioner.
+var DefaultProvisioner Provisioner = Terraform{}
+
+// Provisions the resources in a terraform config directory with the
+// DefaultProvisioner, returns the pubsub_info output and a cleanup function
+// to teardown the created resources. Config directories without a
+// pubsub_info output, e.g. for the collector, get back an empty PubsubInfo.
 //
-// Cleanup method runs terraform destroy and then deletes the workspace.
+// The returned cleanup function is never nil, even on error.
 func SetupTf(
 	ctx context.Context,
 	projectID string,
@@ -87,75 +79,48 @@ func SetupTf(
 	tfVars map[string]string, // key-values for terraform input vars to send to terraform
 	logger *log.Logger,
 ) (*PubsubInfo, func(), error) {
-	tfVarArgs := tfVarMapToArgs(projectID, tfVars)
-	cmd := initCommand(ctx, projectID)
-	cmd.Args = append(cmd.Args, tfVarArgs...)
-	cmd.Dir = tfDir
-	if err := runWithOutput(cmd, logger); err != nil {
-		return nil, func() {}, err
-	}
-
-	logger.Printf("Running %s with image: %s\n", tfDir, tfVars["image"])
-
-	cleanup := func() {
-		defer deleteWorkspace(ctx, testRunID, tfDir, logger)
-
-		// Run terraform destroy
-		cmd = exec.CommandContext(
-			ctx,
-			"terraform",
-			"destroy",
-			"-input=false",
-			"-auto-approve",
-		)
-		cmd.Args = append(cmd.Args, tfVarArgs...)
-		cmd.Dir = tfDir
-		if err := runWithOutput(cmd, logger); err != nil {
-			logger.Panic(err)
-		}
-	}
-
-	// Create new terraform workspace
-	cmd = exec.CommandContext(ctx, "terraform", "workspace", "new", testRunID)
-	cmd.Dir = tfDir
-	if err := runWithOutput(cmd, logger); err != nil {
-		// try to switch to workspace if it already exists
-		cmd = exec.CommandContext(ctx, "terraform", "workspace", "select", testRunID)
-		cmd.Dir = tfDir
-
-		if err := runWithOutput(cmd, logger); err != nil {
-			return nil, cleanup, err
-		}
+	outputs, destroy, err := SetupTfOutputs(ctx, projectID, testRunID, tfDir, tfVars, logger)
+	if err != nil {
+		return nil, destroy, err
 	}
 
-	// Run terraform apply
-	cmd = exec.CommandContext(
-		ctx,
-		"terraform",
-		"apply",
-		"-input=false",
-		"-auto-approve",
-	)
-	cmd.Args = append(cmd.Args, tfVarArgs...)
-	cmd.Dir = tfDir
-	if err := runWithOutput(cmd, logger); err != nil {
-		return nil, cleanup, err
-	}
+	pubsubInfo, err := outputs.PubsubInfo()
+	return pubsubInfo, destroy, err
+}
 
-	// Run terraform output
-	cmd = exec.CommandContext(ctx, "terraform", "output", "-json")
-	cmd.Dir = tfDir
-	out, err := cmd.Output()
-	if err != nil {
-		logger.Println(err)
-		return nil, cleanup, err
+// Like SetupTf, but returns all of the config's outputs, e.g. to decode into
+// an environment specific struct with Outputs.DecodeAll.
+//
+// The returned cleanup function is never nil, even on error.
+func SetupTfOutputs(
+	ctx context.Context,
+	projectID string,
+	testRunID string,
+	tfDir string,
+	tfVars map[string]string,
+	logger *log.Logger,
+) (Outputs, func(), error) {
+	outputs, destroy, err := DefaultProvisioner.Apply(ctx, ApplyRequest{
+		ProjectID: projectID,
+		TestRunID: testRunID,
+		Dir:       tfDir,
+		Vars:      tfVars,
+		Reuse:     ReuseWorkspace,
+	}, logger)
+	if !KeepEnvironment {
+		return outputs, destroy, err
 	}
 
-	tfOutput := &tfOutput{}
-	if err := json.Unmarshal(out, tfOutput); err != nil {
-		return nil, cleanup, err
+	keep := func() {
+		logger.Printf(
+			"Keeping the resources of %v in workspace %v, pass --reuse-test-run-id=%v to run against them again\n",
+			tfDir,
+			testRunID,
+			testRunID,
+		)
 	}
-	return &tfOutput.PubsubInfoWrapper.Value, cleanup, nil
+	keep()
+	return outputs, keep, err
 }
 
 func ApplyPersistent(
@@ -186,24 +151,25 @@ func applyPersistent(
 	persistentDir string,
 ) error {
 	logger.Println("Applying any changes to persistent resources")
+	c, err := detectCLI(ctx, logger)
+	if err != nil {
+		return err
+	}
+
 	// Run terraform init
-	cmd := initCommand(ctx, projectID)
-	cmd.Dir = persistentDir
-	if err := runWithOutput(cmd, logger); err != nil {
+	if err := runWithOutput(c.initCommand(ctx, persistentDir, projectID), logger); err != nil {
 		return err
 	}
 
 	// Select default terraform workspace
-	cmd = exec.CommandContext(ctx, "terraform", "workspace", "select", "default")
-	cmd.Dir = tfPersistentDir
-	if err := runWithOutput(cmd, logger); err != nil {
+	if err := runWithOutput(c.command(ctx, tfPersistentDir, "workspace", "select", "default"), logger); err != nil {
 		return err
 	}
 
 	// Run terraform apply
-	cmd = exec.CommandContext(
+	cmd := c.command(
 		ctx,
-		"terraform",
+		tfPersistentDir,
 		"apply",
 		"-input=false",
 		// lock may not be acquired immediately in CI if there are multiple
@@ -216,42 +182,9 @@ func applyPersistent(
 	} else {
 		cmd.Stdin = os.Stdin
 	}
-	cmd.Dir = tfPersistentDir
 	if err := runWithOutput(cmd, logger); err != nil {
 		return err
 	}
 
 	return nil
 }
-
-func deleteWorkspace(
-	ctx context.Context,
-	testRunID string,
-	tfDir string, // the Dir to set when running terraform commands in e.g. tf/gke
-	logger *log.Logger,
-) {
-	// first, switch to default terraform workspace
-	cmd := exec.CommandContext(ctx, "terraform", "workspace", "select", "default")
-	cmd.Dir = tfDir
-	if err := runWithOutput(cmd, logger); err != nil {
-		logger.Panic(err)
-	}
-
-	// issue delete
-	cmd = exec.CommandContext(ctx, "terraform", "workspace", "delete", testRunID)
-	cmd.Dir = tfDir
-	if err := runWithOutput(cmd, logger); err != nil {
-		logger.Panic(err)
-	}
-}
-
-func tfVarMapToArgs(
-	projectID string,
-	tfVars map[string]string,
-) []string {
-	tfVarArgs := []string{fmt.Sprintf("-var=project_id=%v", projectID)}
-	for k, v := range tfVars {
-		tfVarArgs = append(tfVarArgs, fmt.Sprintf("-var=%v=%v", k, v))
-	}
-	return tfVarArgs
-}
@@ -0,0 +1,128 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setuptf_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf/fakeprovisioner"
+)
+
+var discardLogger = log.New(io.Discard, "", 0)
+
+func TestSetupTf(t *testing.T) {
+	expected := &setuptf.PubsubInfo{
+		RequestTopic:  setuptf.TopicInfo{TopicName: "request", SubscriptionName: "request-sub"},
+		ResponseTopic: setuptf.TopicInfo{TopicName: "response", SubscriptionName: "response-sub"},
+	}
+	fake := fakeprovisioner.New(expected)
+	fakeprovisioner.Install(t, fake)
+
+	pubsubInfo, cleanup, err := setuptf.SetupTf(
+		context.Background(),
+		"project",
+		"abc123",
+		"tf/gce",
+		map[string]string{"image": "foo:latest"},
+		discardLogger,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, expected, pubsubInfo)
+	expectReq := setuptf.ApplyRequest{
+		ProjectID: "project",
+		TestRunID: "abc123",
+		Dir:       "tf/gce",
+		Vars:      map[string]string{"image": "foo:latest"},
+	}
+	assert.Equal(t, []setuptf.ApplyRequest{expectReq}, fake.Applied())
+	assert.Empty(t, fake.Destroyed())
+
+	cleanup()
+	assert.Equal(t, []setuptf.ApplyRequest{expectReq}, fake.Destroyed())
+}
+
+func TestSetupTfError(t *testing.T) {
+	fake := &fakeprovisioner.Provisioner{Err: errors.New("apply failed")}
+	fakeprovisioner.Install(t, fake)
+
+	_, cleanup, err := setuptf.SetupTf(context.Background(), "project", "abc123", "tf/gce", nil, discardLogger)
+	assert.EqualError(t, err, "apply failed")
+	require.NotNil(t, cleanup)
+	cleanup()
+	assert.Len(t, fake.Destroyed(), 1)
+}
+
+func TestSetupTfOutputs(t *testing.T) {
+	fake := &fakeprovisioner.Provisioner{Outputs: setuptf.Outputs{
+		setuptf.PubsubInfoOutput: []byte(`{"request_topic": {"topic_name": "request"}}`),
+		"service_url":            []byte(`"https://e2etest-abc123.a.run.app"`),
+		"replicas":               []byte(`3`),
+	}}
+	fakeprovisioner.Install(t, fake)
+
+	outputs, cleanup, err := setuptf.SetupTfOutputs(context.Background(), "project", "abc123", "tf/cloud-run", nil, discardLogger)
+	require.NoError(t, err)
+	defer cleanup()
+
+	var serviceURL string
+	require.NoError(t, outputs.Decode("service_url", &serviceURL))
+	assert.Equal(t, "https://e2etest-abc123.a.run.app", serviceURL)
+	assert.ErrorIs(t, outputs.Decode("instance_name", &serviceURL), setuptf.ErrNoOutput)
+	var wrongType int
+	assert.Error(t, outputs.Decode("service_url", &wrongType))
+
+	all := struct {
+		PubsubInfo   setuptf.PubsubInfo `json:"pubsub_info"`
+		ServiceURL   string             `json:"service_url"`
+		InstanceName string             `json:"instance_name"`
+	}{InstanceName: "unchanged"}
+	require.NoError(t, outputs.DecodeAll(&all))
+	assert.Equal(t, "request", all.PubsubInfo.RequestTopic.TopicName)
+	assert.Equal(t, "https://e2etest-abc123.a.run.app", all.ServiceURL)
+	assert.Equal(t, "unchanged", all.InstanceName)
+}
+
+func TestPubsubInfoMissing(t *testing.T) {
+	pubsubInfo, err := setuptf.Outputs{}.PubsubInfo()
+	require.NoError(t, err)
+	assert.Equal(t, &setuptf.PubsubInfo{}, pubsubInfo)
+}
+
+func TestSetupTfKeepAndReuse(t *testing.T) {
+	fake := &fakeprovisioner.Provisioner{}
+	fakeprovisioner.Install(t, fake)
+	setuptf.KeepEnvironment = true
+	setuptf.ReuseWorkspace = true
+	t.Cleanup(func() {
+		setuptf.KeepEnvironment = false
+		setuptf.ReuseWorkspace = false
+	})
+
+	_, cleanup, err := setuptf.SetupTf(context.Background(), "project", "abc123", "tf/gce", nil, discardLogger)
+	require.NoError(t, err)
+	require.Len(t, fake.Applied(), 1)
+	assert.True(t, fake.Applied()[0].Reuse)
+
+	cleanup()
+	assert.Empty(t, fake.Destroyed())
+}
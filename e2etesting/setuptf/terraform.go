@@ -0,0 +1,246 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setuptf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// Terraform is the Provisioner which runs the terraform CLI, or a compatible
+// one like OpenTofu, in the config directory. See IacBinary.
+type Terraform struct{}
+
+var _ Provisioner = Terraform{}
+
+// Runs the sequence of terraform commands most environemnts need, returns the
+// outputs of `terraform output -json` and a cleanup function to teardown the
+// created resources.
+//
+//  1. Run terraform init
+//  2. Create a new terraform workspace for the test run ID, or select the
+//     existing one when reusing it
+//  3. Run terraform apply, skipped when reusing a workspace whose plan has no
+//     changes
+//  4. Get output results from terraform output
+//
+// Cleanup method runs terraform destroy and then deletes the workspace.
+//
+// Each test run gets a data directory of its own, which holds the selected
+// workspace, so test runs of the same config directory can be set up
+// concurrently, e.g. a platform repeated in the matrix subcommand. Only their
+// init runs one at a time.
+func (Terraform) Apply(ctx context.Context, req ApplyRequest, logger *log.Logger) (Outputs, Destroy, error) {
+	c, err := detectCLI(ctx, logger)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	c.dataDir = runDataDir(req.TestRunID)
+
+	tfDir := req.Dir
+	tfVarArgs := tfVarMapToArgs(req.ProjectID, req.Vars)
+	if err := c.init(ctx, tfDir, req.ProjectID, tfVarArgs, logger); err != nil {
+		return nil, func() {}, err
+	}
+
+	logger.Printf("Running %s with image: %s\n", tfDir, req.Vars["image"])
+
+	cleanup := func() {
+		// Still destroy when ctx was canceled, e.g. by an interrupted test run
+		ctx := context.WithoutCancel(ctx)
+		defer os.RemoveAll(filepath.Join(tfDir, c.dataDir))
+		defer deleteWorkspace(ctx, c, req.TestRunID, tfDir, logger)
+
+		// Run terraform destroy
+		cmd := c.command(ctx, tfDir, "destroy", "-input=false", "-auto-approve")
+		cmd.Args = append(cmd.Args, tfVarArgs...)
+		if err := runWithOutput(cmd, logger); err != nil {
+			logger.Panic(err)
+		}
+	}
+
+	apply := true
+	if req.Reuse {
+		// The workspace should already exist, don't create an empty one
+		if err := runWithOutput(c.command(ctx, tfDir, "workspace", "select", req.TestRunID), logger); err != nil {
+			return nil, cleanup, fmt.Errorf("failed to select workspace %v to reuse: %w", req.TestRunID, err)
+		}
+		changed, err := c.planHasChanges(ctx, tfDir, tfVarArgs, logger)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		if !changed {
+			logger.Printf("Inputs of workspace %v are unchanged, skipping apply\n", req.TestRunID)
+		}
+		apply = changed
+	} else if err := c.selectOrCreateWorkspace(ctx, tfDir, req.TestRunID, logger); err != nil {
+		return nil, cleanup, err
+	}
+
+	// Run terraform apply
+	if apply {
+		cmd := c.command(ctx, tfDir, "apply", "-input=false", "-auto-approve")
+		cmd.Args = append(cmd.Args, tfVarArgs...)
+		if err := runWithOutput(cmd, logger); err != nil {
+			return nil, cleanup, err
+		}
+	}
+
+	// Run terraform output
+	out, err := c.command(ctx, tfDir, "output", "-json").Output()
+	if err != nil {
+		logger.Println(err)
+		return nil, cleanup, err
+	}
+
+	outputs, err := parseOutputs(out)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	return outputs, cleanup, nil
+}
+
+// The TF_DATA_DIR of a test run, relative to the config directory and ignored
+// by tf/.gitignore like the default .terraform
+func runDataDir(testRunID string) string {
+	return filepath.Join(".terraform", "runs", testRunID)
+}
+
+// Locks of the config directories, by absolute path. Test runs have their own
+// data directories, but init still writes the config directory's
+// .terraform.lock.hcl and fills the shared plugin cache, which isn't safe to do
+// concurrently.
+var initLocks sync.Map
+
+// Runs terraform init, one at a time per config directory
+func (c *cli) init(ctx context.Context, dir string, projectID string, tfVarArgs []string, logger *log.Logger) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	lock, _ := initLocks.LoadOrStore(absDir, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	cmd := c.initCommand(ctx, dir, projectID)
+	cmd.Args = append(cmd.Args, tfVarArgs...)
+	return runWithOutput(cmd, logger)
+}
+
+// Parses the output of `terraform output -json`, which wraps each value with
+// its type and sensitivity. Some versions print nothing at all instead of {}
+// when there are no outputs.
+func parseOutputs(out []byte) (Outputs, error) {
+	outputs := Outputs{}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return outputs, nil
+	}
+	tfOutputs := map[string]struct {
+		Value json.RawMessage `json:"value"`
+	}{}
+	if err := json.Unmarshal(out, &tfOutputs); err != nil {
+		return nil, err
+	}
+	for name, output := range tfOutputs {
+		outputs[name] = output.Value
+	}
+	return outputs, nil
+}
+
+func runWithOutput(cmd *exec.Cmd, logger *log.Logger) error {
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	logger.Printf("Running command: %v\n", cmd)
+	if err := cmd.Run(); err != nil {
+		logger.Println(err)
+		return err
+	}
+	return nil
+}
+
+func deleteWorkspace(
+	ctx context.Context,
+	c *cli,
+	testRunID string,
+	tfDir string, // the Dir to set when running terraform commands in e.g. tf/gke
+	logger *log.Logger,
+) {
+	// first, switch to default terraform workspace
+	if err := runWithOutput(c.command(ctx, tfDir, "workspace", "select", "default"), logger); err != nil {
+		logger.Panic(err)
+	}
+
+	// issue delete
+	if err := runWithOutput(c.command(ctx, tfDir, "workspace", "delete", testRunID), logger); err != nil {
+		logger.Panic(err)
+	}
+}
+
+// DestroyWorkspace destroys everything in an existing workspace of a config
+// directory and then deletes the workspace, e.g. for a test run which was
+// killed before its cleanup ran. State isn't refreshed before destroying, so
+// tfVars only need to be valid enough for the config to evaluate.
+func DestroyWorkspace(
+	ctx context.Context,
+	projectID string,
+	workspace string,
+	tfDir string,
+	tfVars map[string]string,
+	logger *log.Logger,
+) error {
+	c, err := detectCLI(ctx, logger)
+	if err != nil {
+		return err
+	}
+
+	tfVarArgs := tfVarMapToArgs(projectID, tfVars)
+	if err := c.init(ctx, tfDir, projectID, tfVarArgs, logger); err != nil {
+		return err
+	}
+
+	// Don't use -or-create, the workspace should already exist
+	if err := runWithOutput(c.command(ctx, tfDir, "workspace", "select", workspace), logger); err != nil {
+		return err
+	}
+
+	cmd := c.command(ctx, tfDir, "destroy", "-input=false", "-auto-approve", "-refresh=false", "-lock-timeout=10m")
+	cmd.Args = append(cmd.Args, tfVarArgs...)
+	if err := runWithOutput(cmd, logger); err != nil {
+		return err
+	}
+
+	if err := runWithOutput(c.command(ctx, tfDir, "workspace", "select", "default"), logger); err != nil {
+		return err
+	}
+	return runWithOutput(c.command(ctx, tfDir, "workspace", "delete", workspace), logger)
+}
+
+func tfVarMapToArgs(
+	projectID string,
+	tfVars map[string]string,
+) []string {
+	tfVarArgs := []string{fmt.Sprintf("-var=project_id=%v", projectID)}
+	for k, v := range tfVars {
+		tfVarArgs = append(tfVarArgs, fmt.Sprintf("-var=%v=%v", k, v))
+	}
+	return tfVarArgs
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setuptf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputs(t *testing.T) {
+	out := []byte(`{
+		"pubsub_info": {
+			"sensitive": false,
+			"type": ["object", {}],
+			"value": {
+				"request_topic": {"topic_name": "request", "subscription_name": "request-sub"},
+				"response_topic": {"topic_name": "response", "subscription_name": "response-sub"}
+			}
+		},
+		"service_url": {"sensitive": false, "type": "string", "value": "https://example.com"}
+	}`)
+
+	outputs, err := parseOutputs(out)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"https://example.com"`, string(outputs["service_url"]))
+	pubsubInfo, err := outputs.PubsubInfo()
+	require.NoError(t, err)
+	assert.Equal(t, &PubsubInfo{
+		RequestTopic:  TopicInfo{TopicName: "request", SubscriptionName: "request-sub"},
+		ResponseTopic: TopicInfo{TopicName: "response", SubscriptionName: "response-sub"},
+	}, pubsubInfo)
+}
+
+func TestPubsubInfoMissing(t *testing.T) {
+	pubsubInfo, err := Outputs{}.PubsubInfo()
+	require.NoError(t, err)
+	assert.Equal(t, &PubsubInfo{}, pubsubInfo)
+}
+
+func TestTfVarMapToArgs(t *testing.T) {
+	args := tfVarMapToArgs("project", map[string]string{"image": "foo:latest"})
+	assert.Equal(t, []string{"-var=project_id=project", "-var=image=foo:latest"}, args)
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2etesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Teardown runs the cleanups of a test run's setups exactly once, in reverse
+// order, whether TestMain returns, panics or the process gets SIGINT/SIGTERM,
+// e.g. from Ctrl-C or a Cloud Build timeout. A cleanup which panics doesn't
+// stop the rest from running, its panic is reported as an error instead.
+type Teardown struct {
+	logger  *log.Logger
+	ctx     context.Context
+	cancel  context.CancelFunc
+	signals chan os.Signal
+	exit    func(code int)
+
+	mu sync.Mutex
+	// Signaled when a Setup returns
+	setupDone *sync.Cond
+	setups    int
+	steps     []teardownStep
+	ran       bool
+
+	once sync.Once
+	err  error
+}
+
+type teardownStep struct {
+	name    string
+	cleanup Cleanup
+}
+
+// NewTeardown starts handling SIGINT and SIGTERM. On the first signal, it
+// cancels Context(), tears down and exits. A second signal exits right away.
+func NewTeardown(ctx context.Context, logger *log.Logger) *Teardown {
+	t := newTeardown(ctx, logger, os.Exit)
+	signal.Notify(t.signals, syscall.SIGINT, syscall.SIGTERM)
+	go t.handleSignals()
+	return t
+}
+
+func newTeardown(ctx context.Context, logger *log.Logger, exit func(int)) *Teardown {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &Teardown{
+		logger:  logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		signals: make(chan os.Signal, 2),
+		exit:    exit,
+	}
+	t.setupDone = sync.NewCond(&t.mu)
+	return t
+}
+
+func (t *Teardown) handleSignals() {
+	sig := <-t.signals
+	t.logger.Printf("Received %v, tearing down before exiting\n", sig)
+	t.cancel()
+	go func() {
+		sig := <-t.signals
+		t.logger.Printf("Received %v again, exiting without finishing the teardown\n", sig)
+		t.exit(1)
+	}()
+	t.Run()
+	t.exit(1)
+}
+
+// Context is canceled when a signal is received, which interrupts any setup
+// still running.
+func (t *Teardown) Context() context.Context {
+	return t.ctx
+}
+
+// Add registers a cleanup to run on teardown. If the teardown already ran, the
+// cleanup runs right away instead.
+func (t *Teardown) Add(name string, cleanup Cleanup) {
+	t.mu.Lock()
+	if !t.ran {
+		t.steps = append(t.steps, teardownStep{name: name, cleanup: cleanup})
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+
+	t.logger.Printf("Teardown already ran, cleaning up %v now\n", name)
+	if err := runStep(teardownStep{name: name, cleanup: cleanup}); err != nil {
+		t.logger.Printf("Could not tear down %v\n", err)
+	}
+}
+
+// Setup runs a setup function with Context() and registers the cleanup it
+// returns, even if it fails. A teardown triggered by a signal waits for
+// running setups to return, so their cleanups aren't missed.
+func (t *Teardown) Setup(name string, setup func(ctx context.Context) (Cleanup, error)) error {
+	t.mu.Lock()
+	if t.ran {
+		t.mu.Unlock()
+		return fmt.Errorf("not setting up %v, teardown already ran", name)
+	}
+	t.setups++
+	t.mu.Unlock()
+
+	var cleanup Cleanup
+	defer func() {
+		if cleanup != nil {
+			t.Add(name, cleanup)
+		}
+		t.mu.Lock()
+		t.setups--
+		t.setupDone.Broadcast()
+		t.mu.Unlock()
+	}()
+	var err error
+	cleanup, err = setup(t.ctx)
+	return err
+}
+
+// Run tears down once and logs what could not be torn down. Later calls wait
+// for the first one and return its error.
+func (t *Teardown) Run() error {
+	t.once.Do(func() {
+		t.mu.Lock()
+		for t.setups > 0 {
+			t.setupDone.Wait()
+		}
+		t.ran = true
+		steps := t.steps
+		t.steps = nil
+		t.mu.Unlock()
+
+		var errs []error
+		for i := len(steps) - 1; i >= 0; i-- {
+			t.logger.Printf("Tearing down %v\n", steps[i].name)
+			if err := runStep(steps[i]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		t.err = errors.Join(errs...)
+		t.report(errs)
+		signal.Stop(t.signals)
+	})
+	return t.err
+}
+
+// RunOnExit is meant to be deferred in TestMain. It tears down, also when
+// TestMain panics, and exits with a failure if anything could not be torn
+// down.
+func (t *Teardown) RunOnExit() {
+	if r := recover(); r != nil {
+		t.logger.Printf("Panic in TestMain, tearing down: %v\n%s", r, debug.Stack())
+		t.Run()
+		panic(r)
+	}
+	if err := t.Run(); err != nil {
+		t.exit(1)
+	}
+}
+
+func (t *Teardown) report(errs []error) {
+	if len(errs) == 0 {
+		t.logger.Println("Teardown finished")
+		return
+	}
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = fmt.Sprintf("  - %v", err)
+	}
+	t.logger.Printf(
+		"Teardown failed, these may not have been destroyed (see also the janitor subcommand):\n%v\n",
+		strings.Join(lines, "\n"),
+	)
+}
+
+func runStep(step teardownStep) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v: %v", step.name, r)
+		}
+	}()
+	step.cleanup()
+	return nil
+}
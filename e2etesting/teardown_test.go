@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2etesting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu  sync.Mutex
+	ran []string
+}
+
+func (r *recorder) cleanup(name string) Cleanup {
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.ran = append(r.ran, name)
+	}
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.ran...)
+}
+
+func newTestTeardown(t *testing.T) (*Teardown, *bytes.Buffer, chan int) {
+	var logs bytes.Buffer
+	exits := make(chan int, 2)
+	td := newTeardown(context.Background(), log.New(&logs, "", 0), func(code int) { exits <- code })
+	return td, &logs, exits
+}
+
+func TestTeardownReverseOrder(t *testing.T) {
+	td, logs, _ := newTestTeardown(t)
+	r := &recorder{}
+	td.Add("first", r.cleanup("first"))
+	td.Add("second", r.cleanup("second"))
+
+	require.NoError(t, td.Run())
+	assert.Equal(t, []string{"second", "first"}, r.get())
+	assert.Contains(t, logs.String(), "Teardown finished")
+
+	// Only runs once
+	require.NoError(t, td.Run())
+	assert.Len(t, r.get(), 2)
+}
+
+func TestTeardownCollectsPanics(t *testing.T) {
+	td, logs, _ := newTestTeardown(t)
+	r := &recorder{}
+	td.Add("first", r.cleanup("first"))
+	td.Add("workspace abc123", func() { log.New(&bytes.Buffer{}, "", 0).Panic("destroy failed") })
+	td.Add("third", r.cleanup("third"))
+
+	err := td.Run()
+	assert.EqualError(t, err, "workspace abc123: destroy failed")
+	assert.Equal(t, []string{"third", "first"}, r.get())
+	assert.Contains(t, logs.String(), "these may not have been destroyed")
+	assert.Contains(t, logs.String(), "  - workspace abc123: destroy failed")
+}
+
+func TestTeardownAddAfterRun(t *testing.T) {
+	td, _, _ := newTestTeardown(t)
+	require.NoError(t, td.Run())
+
+	r := &recorder{}
+	td.Add("late", r.cleanup("late"))
+	assert.Equal(t, []string{"late"}, r.get())
+
+	err := td.Setup("too late", func(ctx context.Context) (Cleanup, error) {
+		t.Error("setup should not run after teardown")
+		return nil, nil
+	})
+	assert.Error(t, err)
+}
+
+func TestTeardownSetupRegistersOnError(t *testing.T) {
+	td, _, _ := newTestTeardown(t)
+	r := &recorder{}
+
+	err := td.Setup("env", func(ctx context.Context) (Cleanup, error) {
+		return r.cleanup("env"), errors.New("apply failed")
+	})
+	assert.EqualError(t, err, "apply failed")
+	require.NoError(t, td.Run())
+	assert.Equal(t, []string{"env"}, r.get())
+}
+
+func TestTeardownOnSignal(t *testing.T) {
+	td, _, exits := newTestTeardown(t)
+	go td.handleSignals()
+	r := &recorder{}
+	td.Add("first", r.cleanup("first"))
+
+	// The signal arrives while a setup is still running. The teardown waits
+	// for the interrupted setup so its cleanup runs too.
+	setupStarted := make(chan struct{})
+	setupErr := make(chan error)
+	go func() {
+		setupErr <- td.Setup("env", func(ctx context.Context) (Cleanup, error) {
+			close(setupStarted)
+			<-ctx.Done()
+			return r.cleanup("env"), ctx.Err()
+		})
+	}()
+	<-setupStarted
+	td.signals <- syscall.SIGTERM
+
+	assert.ErrorIs(t, <-setupErr, context.Canceled)
+	select {
+	case code := <-exits:
+		assert.Equal(t, 1, code)
+	case <-time.After(10 * time.Second):
+		t.Fatal("did not exit after the signal")
+	}
+	assert.Equal(t, []string{"env", "first"}, r.get())
+}
+
+func TestTeardownRunOnExitPanic(t *testing.T) {
+	td, _, exits := newTestTeardown(t)
+	r := &recorder{}
+	td.Add("env", r.cleanup("env"))
+
+	assert.PanicsWithValue(t, "setup failed", func() {
+		defer td.RunOnExit()
+		panic("setup failed")
+	})
+	assert.Equal(t, []string{"env"}, r.get())
+	assert.Empty(t, exits)
+}
+
+func TestTeardownRunOnExitFailure(t *testing.T) {
+	td, _, exits := newTestTeardown(t)
+	td.Add("env", func() { panic("destroy failed") })
+
+	td.RunOnExit()
+	assert.Equal(t, 1, <-exits)
+}
@@ -0,0 +1,389 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package expectations loads declarative scenario expectations from the
+// versioned YAML files embedded in this package, and checks the traces test
+// servers write against them. See traces.yaml for the format. The monitored
+// resources metrics and logs are written to on each platform are declared in
+// resources.yaml.
+package expectations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cloudtrace "google.golang.org/api/cloudtrace/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// The only version of the file format so far. Bump it for incompatible
+// changes to the format, and keep loading older versions where possible.
+const Version = 1
+
+// Replaced with the quoted test ID in label regexes
+const TestIDPlaceholder = "${TEST_ID}"
+
+//go:embed *.yaml
+var embedded embed.FS
+
+// File is a single YAML file of expectations
+type File struct {
+	Version   int        `yaml:"version"`
+	Scenarios []Scenario `yaml:"scenarios"`
+	// Monitored resources keyed by platform
+	Resources map[string]Resources `yaml:"resources"`
+}
+
+// Scenario is what the runner expects to find in the backend after sending
+// one scenario request to the test server
+type Scenario struct {
+	// Unique name of the expectation
+	Name string `yaml:"name"`
+	// The go test function which runs the expectation, so that results keep
+	// their names in reports. If empty, it runs as a subtest of
+	// TestDeclaredScenarios.
+	Test string `yaml:"test"`
+	// The scenario to request from the test server, e.g. /basicTrace
+	Scenario string `yaml:"scenario"`
+	// Platforms to skip the scenario on, with the reason
+	Skip map[string]string `yaml:"skip"`
+	// If non-zero, the exact number of spans in the trace
+	SpanCount int `yaml:"spanCount"`
+	// Spans to look for in the trace by name
+	Spans []Span `yaml:"spans"`
+}
+
+// Span is an expected span, looked up by name
+type Span struct {
+	Name string `yaml:"name"`
+	// Whether the span must not have a parent
+	Root bool `yaml:"root"`
+	// The name of the parent span, if any
+	Parent string `yaml:"parent"`
+	// The Cloud Trace v1 span kind, e.g. RPC_SERVER
+	Kind string `yaml:"kind"`
+	// If set, the exact number of labels which don't come from the resource,
+	// instrumentation scope or environment
+	NonResourceLabelCount *int `yaml:"nonResourceLabelCount"`
+	// Labels expected on every platform
+	Labels []Label `yaml:"labels"`
+	// Extra labels expected per platform. If set, the platform must have an
+	// entry.
+	PlatformLabels map[string][]Label `yaml:"platformLabels"`
+}
+
+// Label is a label key and a regex its value must match
+type Label struct {
+	Key   string `yaml:"key"`
+	Regex string `yaml:"regex"`
+}
+
+// Resources are the monitored resources the exporters should map the detected
+// resource to on a platform
+type Resources struct {
+	Metric MonitoredResource `yaml:"metric"`
+	Log    MonitoredResource `yaml:"log"`
+}
+
+// MonitoredResource is a monitored resource type and the labels which must be
+// non-empty
+type MonitoredResource struct {
+	Type   string   `yaml:"type"`
+	Labels []string `yaml:"labels"`
+}
+
+// Load loads all of the expectations embedded in this package.
+func Load() ([]Scenario, error) {
+	return LoadFS(embedded)
+}
+
+// LoadFS loads all of the *.yaml files at the root of fsys.
+func LoadFS(fsys fs.FS) ([]Scenario, error) {
+	files, err := loadFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenarios []Scenario
+	names := map[string]string{}
+	for _, file := range files {
+		for _, scenario := range file.Scenarios {
+			if err := scenario.validate(); err != nil {
+				return nil, fmt.Errorf("invalid scenario %q in %v: %w", scenario.Name, file.path, err)
+			}
+			if other, ok := names[scenario.Name]; ok {
+				return nil, fmt.Errorf("scenario %q in %v is already declared in %v", scenario.Name, file.path, other)
+			}
+			names[scenario.Name] = file.path
+			scenarios = append(scenarios, scenario)
+		}
+	}
+	return scenarios, nil
+}
+
+// LoadResources loads the monitored resources of each platform embedded in
+// this package.
+func LoadResources() (map[string]Resources, error) {
+	return LoadResourcesFS(embedded)
+}
+
+// LoadResourcesFS loads the monitored resources of each platform from all of
+// the *.yaml files at the root of fsys.
+func LoadResourcesFS(fsys fs.FS) (map[string]Resources, error) {
+	files, err := loadFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := map[string]Resources{}
+	platforms := map[string]string{}
+	for _, file := range files {
+		for platform, platformResources := range file.Resources {
+			if err := platformResources.validate(); err != nil {
+				return nil, fmt.Errorf("invalid resources of platform %q in %v: %w", platform, file.path, err)
+			}
+			if other, ok := platforms[platform]; ok {
+				return nil, fmt.Errorf("resources of platform %q in %v are already declared in %v", platform, file.path, other)
+			}
+			platforms[platform] = file.path
+			resources[platform] = platformResources
+		}
+	}
+	return resources, nil
+}
+
+type loadedFile struct {
+	File
+	path string
+}
+
+// Parses all of the *.yaml files at the root of fsys in order of their paths
+func loadFiles(fsys fs.FS) ([]loadedFile, error) {
+	paths, err := fs.Glob(fsys, "*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var files []loadedFile
+	for _, path := range paths {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		file := File{}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %w", path, err)
+		}
+		if file.Version != Version {
+			return nil, fmt.Errorf("%v has unsupported version %v, expected %v", path, file.Version, Version)
+		}
+		files = append(files, loadedFile{File: file, path: path})
+	}
+	return files, nil
+}
+
+// ForTest returns the scenario which runs as the given go test, or nil if there
+// is none. testName is the full name from t.Name(), which is nested when the
+// test runs under TestMatrix, e.g. TestMatrix/gke/TestBasicTrace.
+func ForTest(scenarios []Scenario, testName string) *Scenario {
+	test := path.Base(testName)
+	for i := range scenarios {
+		if scenarios[i].Test == test {
+			return &scenarios[i]
+		}
+	}
+	return nil
+}
+
+func (s *Scenario) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if !strings.HasPrefix(s.Scenario, "/") {
+		return fmt.Errorf("scenario %q must start with /", s.Scenario)
+	}
+	if len(s.Spans) == 0 {
+		return fmt.Errorf("no spans declared")
+	}
+	spanNames := map[string]bool{}
+	for _, span := range s.Spans {
+		spanNames[span.Name] = true
+	}
+	for _, span := range s.Spans {
+		if span.Root && span.Parent != "" {
+			return fmt.Errorf("span %q can't be a root and have a parent", span.Name)
+		}
+		if span.Parent != "" && !spanNames[span.Parent] {
+			return fmt.Errorf("span %q has undeclared parent %q", span.Name, span.Parent)
+		}
+		for _, labels := range append([][]Label{span.Labels}, mapValues(span.PlatformLabels)...) {
+			for _, label := range labels {
+				if _, err := regexp.Compile(strings.ReplaceAll(label.Regex, TestIDPlaceholder, "")); err != nil {
+					return fmt.Errorf("span %q label %q: %w", span.Name, label.Key, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Resources) validate() error {
+	if r.Metric.Type == "" {
+		return fmt.Errorf("missing metric resource type")
+	}
+	if r.Log.Type == "" {
+		return fmt.Errorf("missing log resource type")
+	}
+	return nil
+}
+
+// SkipReason returns why the scenario shouldn't run on the platform, if it
+// shouldn't.
+func (s *Scenario) SkipReason(platform string) (string, bool) {
+	reason, ok := s.Skip[platform]
+	return reason, ok
+}
+
+// CheckTrace checks the trace the test server wrote for the scenario.
+func (s *Scenario) CheckTrace(t *testing.T, trace *cloudtrace.Trace, platform string, testID string) {
+	if len(trace.Spans) == 0 {
+		t.Fatalf("Got zero spans in trace %v", trace.TraceId)
+	}
+	if s.SpanCount != 0 && len(trace.Spans) != s.SpanCount {
+		t.Fatalf("Got %v spans in trace %v, but expected %v", len(trace.Spans), trace.TraceId, s.SpanCount)
+	}
+
+	spanByName := make(map[string]*cloudtrace.TraceSpan)
+	for _, span := range trace.Spans {
+		spanByName[span.Name] = span
+	}
+
+	for _, expect := range s.Spans {
+		span := spanByName[expect.Name]
+		require.NotNilf(t, span, "Missing span named %v", expect.Name)
+		t.Run(fmt.Sprintf("assert structure of span %v", expect.Name), func(t *testing.T) {
+			checkStructure(t, expect, span, spanByName)
+		})
+
+		labels, err := expect.labelsFor(platform)
+		require.NoError(t, err)
+		if expect.NonResourceLabelCount != nil {
+			nonResourceLabels := nonResourceLabels(span)
+			if len(nonResourceLabels) != *expect.NonResourceLabelCount {
+				t.Fatalf(
+					"Expected exactly %v non-resource labels, got %v. Labels found: %v",
+					*expect.NonResourceLabelCount,
+					len(nonResourceLabels),
+					nonResourceLabels,
+				)
+			}
+		}
+		for _, label := range labels {
+			expectRe := strings.ReplaceAll(label.Regex, TestIDPlaceholder, regexp.QuoteMeta(testID))
+			t.Run(fmt.Sprintf("Span has label %v", label.Key), func(t *testing.T) {
+				val, ok := span.Labels[label.Key]
+				assert.Truef(t, ok, `Missing label "%v"`, label.Key)
+				assert.Regexpf(
+					t,
+					expectRe,
+					val,
+					`For label key %v, value "%v" did not match regex "%v"`,
+					label.Key,
+					val,
+					expectRe,
+				)
+			})
+		}
+	}
+}
+
+func checkStructure(t *testing.T, expect Span, span *cloudtrace.TraceSpan, spanByName map[string]*cloudtrace.TraceSpan) {
+	if expect.Root {
+		assert.EqualValuesf(
+			t,
+			0,
+			span.ParentSpanId,
+			"Expected no parent, but got %v",
+			span.ParentSpanId,
+		)
+	}
+	if expect.Parent != "" {
+		parentSpan := spanByName[expect.Parent]
+		if parentSpan == nil {
+			t.Errorf("The parent span %v does not exist in the trace", expect.Parent)
+		} else if parentSpan.SpanId != span.ParentSpanId {
+			t.Errorf("Expected parent span ID %v, but got %v", parentSpan.SpanId, span.ParentSpanId)
+		}
+	}
+	if expect.Kind != "" && span.Kind != expect.Kind {
+		t.Errorf("Expected span kind %v, but got %v", expect.Kind, span.Kind)
+	}
+}
+
+func (s *Span) labelsFor(platform string) ([]Label, error) {
+	if len(s.PlatformLabels) == 0 {
+		return s.Labels, nil
+	}
+	platformLabels, ok := s.PlatformLabels[platform]
+	if !ok {
+		return nil, fmt.Errorf(
+			"unexpected GCP environment %q provided for span %v. Make sure to add handling for all expected GCP environments",
+			platform,
+			s.Name,
+		)
+	}
+	return append(append([]Label(nil), s.Labels...), platformLabels...), nil
+}
+
+// Labels which aren't set from the span's own attributes
+func nonResourceLabels(span *cloudtrace.TraceSpan) []string {
+	var labels []string
+	for key := range span.Labels {
+		if strings.HasPrefix(key, "g.co/r/") {
+			continue
+		}
+		if strings.HasPrefix(key, "otel.scope") {
+			continue
+		}
+		// Some extra kubernetes attributes are added through environment variables on GKE.
+		// In scenarios without resource detection enabled, these show up as trace attributes.
+		if strings.HasPrefix(key, "k8s.") {
+			continue
+		}
+		// Ignore specific GCP resource labels that are known to be added by the exporter.
+		if key == "gcp.project_id" {
+			continue
+		}
+		labels = append(labels, key)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+func mapValues[K comparable, V any](m map[K]V) []V {
+	values := make([]V, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,230 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expectations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cloudtrace "google.golang.org/api/cloudtrace/v1"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	scenarios, err := Load()
+	require.NoError(t, err)
+
+	tests := map[string]string{}
+	for _, scenario := range scenarios {
+		tests[scenario.Test] = scenario.Scenario
+	}
+	assert.Equal(t, "/basicTrace", tests["TestBasicTrace"])
+	assert.Equal(t, "/complexTrace", tests["TestComplexTrace"])
+	assert.Equal(t, "/detectResource", tests["TestResourceDetectionTrace"])
+}
+
+func TestForTest(t *testing.T) {
+	scenarios, err := Load()
+	require.NoError(t, err)
+
+	for _, name := range []string{"TestBasicTrace", "TestMatrix/gke/TestBasicTrace", "TestMatrix/gke-2/TestBasicTrace"} {
+		declared := ForTest(scenarios, name)
+		if assert.NotNilf(t, declared, "no scenario for %v", name) {
+			assert.Equal(t, "/basicTrace", declared.Scenario)
+		}
+	}
+	assert.Nil(t, ForTest(scenarios, "TestMatrix/gke"))
+	assert.Nil(t, ForTest(scenarios, "TestRichTrace"))
+}
+
+func TestLoadFSErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name: "unsupported version",
+			files: fstest.MapFS{"a.yaml": {Data: []byte(`
+version: 2
+scenarios: []
+`)}},
+		},
+		{
+			name: "undeclared parent",
+			files: fstest.MapFS{"a.yaml": {Data: []byte(`
+version: 1
+scenarios:
+  - name: a
+    scenario: /a
+    spans:
+      - name: child
+        parent: root
+`)}},
+		},
+		{
+			name: "duplicate name",
+			files: fstest.MapFS{
+				"a.yaml": {Data: []byte(`
+version: 1
+scenarios:
+  - {name: a, scenario: /a, spans: [{name: a}]}
+`)},
+				"b.yaml": {Data: []byte(`
+version: 1
+scenarios:
+  - {name: a, scenario: /b, spans: [{name: b}]}
+`)},
+			},
+		},
+		{
+			name: "invalid regex",
+			files: fstest.MapFS{"a.yaml": {Data: []byte(`
+version: 1
+scenarios:
+  - name: a
+    scenario: /a
+    spans:
+      - name: a
+        labels: [{key: k, regex: '('}]
+`)}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadFS(tc.files)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadResourcesEmbedded(t *testing.T) {
+	resources, err := LoadResources()
+	require.NoError(t, err)
+
+	for _, platform := range []string{"gce", "gke", "cloud-run", "cloud-functions-gen2", "gae", "gae-standard"} {
+		assert.Containsf(t, resources, platform, "no resources declared for %v", platform)
+	}
+	assert.Equal(t, "k8s_container", resources["gke"].Metric.Type)
+	assert.Equal(t, []string{"region", "function_name"}, resources["cloud-functions-gen2"].Log.Labels)
+
+	// The scenario loader skips the resources
+	_, err = Load()
+	require.NoError(t, err)
+}
+
+func TestLoadResourcesFSErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name: "missing log type",
+			files: fstest.MapFS{"a.yaml": {Data: []byte(`
+version: 1
+resources:
+  gce:
+    metric: {type: gce_instance}
+`)}},
+		},
+		{
+			name: "duplicate platform",
+			files: fstest.MapFS{
+				"a.yaml": {Data: []byte(`
+version: 1
+resources:
+  gce: {metric: {type: a}, log: {type: a}}
+`)},
+				"b.yaml": {Data: []byte(`
+version: 1
+resources:
+  gce: {metric: {type: b}, log: {type: b}}
+`)},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadResourcesFS(tc.files)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCheckTrace(t *testing.T) {
+	count := 3
+	scenario := Scenario{
+		Name:      "test",
+		Scenario:  "/test",
+		SpanCount: 2,
+		Spans: []Span{
+			{
+				Name: "root",
+				Root: true,
+				Labels: []Label{
+					{Key: "test_id", Regex: "^" + TestIDPlaceholder + "$"},
+				},
+				NonResourceLabelCount: &count,
+				PlatformLabels: map[string][]Label{
+					"gce": {{Key: "cloud.platform", Regex: "gcp_compute_engine"}},
+				},
+			},
+			{Name: "child", Parent: "root", Kind: "RPC_SERVER"},
+		},
+	}
+	trace := &cloudtrace.Trace{
+		TraceId: "abc",
+		Spans: []*cloudtrace.TraceSpan{
+			{
+				SpanId: 1,
+				Name:   "root",
+				Labels: map[string]string{
+					"test_id":          "1.5",
+					"cloud.platform":   "gcp_compute_engine",
+					"g.co/r/gce/zone":  "us-central1-a",
+					"otel.scope.name":  "test",
+					"k8s.pod.name":     "pod",
+					"gcp.project_id":   "project",
+					"some.other.label": "value",
+				},
+			},
+			{SpanId: 2, ParentSpanId: 1, Name: "child", Kind: "RPC_SERVER"},
+		},
+	}
+
+	scenario.CheckTrace(t, trace, "gce", "1.5")
+}
+
+func TestLabelsForUnknownPlatform(t *testing.T) {
+	span := Span{
+		Name:           "span",
+		Labels:         []Label{{Key: "a", Regex: ".*"}},
+		PlatformLabels: map[string][]Label{"gce": {{Key: "b", Regex: ".*"}}},
+	}
+
+	labels, err := span.labelsFor("gce")
+	require.NoError(t, err)
+	assert.Len(t, labels, 2)
+	_, err = span.labelsFor("gke")
+	assert.Error(t, err)
+}
+
+func TestSkipReason(t *testing.T) {
+	scenario := Scenario{Skip: map[string]string{"local": "not needed locally"}}
+
+	reason, ok := scenario.SkipReason("local")
+	assert.True(t, ok)
+	assert.Equal(t, "not needed locally", reason)
+	_, ok = scenario.SkipReason("gce")
+	assert.False(t, ok)
+}
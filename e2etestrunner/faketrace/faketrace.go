@@ -0,0 +1,278 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package faketrace is an in-process fake of Cloud Trace for offline runs. Test
+// servers export spans to its gRPC Cloud Trace v2 BatchWriteSpans receiver, and
+// the tests read them back through the Cloud Trace v1 REST
+// projects.traces.get and projects.traces.list endpoints.
+package faketrace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tracepb "cloud.google.com/go/trace/apiv2/tracepb"
+	"google.golang.org/api/cloudtrace/v1"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Labels which Cloud Trace v1 shows a v2 span's error status as, the numeric
+// google.rpc.Code and the message
+const (
+	StatusCodeLabel    = "/status/code"
+	StatusMessageLabel = "/status/message"
+)
+
+// Matches v2 span resource names, projects/[PROJECT_ID]/traces/[TRACE_ID]/spans/[SPAN_ID]
+var spanNameRe = regexp.MustCompile(`^projects/([^/]+)/traces/([0-9a-fA-F]{32})/spans/([0-9a-fA-F]{16})$`)
+
+type Server struct {
+	tracepb.UnimplementedTraceServiceServer
+
+	grpcSrv  *grpc.Server
+	grpcPort int
+	httpSrv  *http.Server
+	httpURL  string
+
+	mu sync.Mutex
+	// v2 spans keyed by lower case hex trace ID
+	spansByTrace map[string][]*tracepb.Span
+}
+
+// Start starts the v2 gRPC receiver on all interfaces, so that the test
+// server container can reach it, and the v1 REST API on localhost.
+func Start(logger *log.Logger) (*Server, error) {
+	grpcLis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return nil, err
+	}
+	httpLis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		grpcLis.Close()
+		return nil, err
+	}
+
+	s := &Server{
+		grpcSrv:      grpc.NewServer(),
+		grpcPort:     grpcLis.Addr().(*net.TCPAddr).Port,
+		httpURL:      fmt.Sprintf("http://%v/", httpLis.Addr()),
+		spansByTrace: map[string][]*tracepb.Span{},
+	}
+	tracepb.RegisterTraceServiceServer(s.grpcSrv, s)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/projects/{projectId}/traces/{traceId}", s.handleGetTrace)
+	mux.HandleFunc("GET /v1/projects/{projectId}/traces", s.handleListTraces)
+	s.httpSrv = &http.Server{Handler: mux}
+
+	go func() {
+		if err := s.grpcSrv.Serve(grpcLis); err != nil {
+			logger.Printf("Fake Cloud Trace gRPC server stopped: %v\n", err)
+		}
+	}()
+	go func() {
+		if err := s.httpSrv.Serve(httpLis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Printf("Fake Cloud Trace HTTP server stopped: %v\n", err)
+		}
+	}()
+	return s, nil
+}
+
+// GRPCPort is the port of the Cloud Trace v2 receiver, listening on all
+// interfaces without TLS.
+func (s *Server) GRPCPort() int {
+	return s.grpcPort
+}
+
+// ClientOptions returns the options to pass to cloudtrace.NewService to read
+// from this fake instead of the real Cloud Trace API.
+func (s *Server) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(s.httpURL),
+		option.WithoutAuthentication(),
+	}
+}
+
+// Spans returns the v2 spans received so far for the hex trace ID.
+func (s *Server) Spans(traceID string) []*tracepb.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*tracepb.Span(nil), s.spansByTrace[strings.ToLower(traceID)]...)
+}
+
+func (s *Server) Close() {
+	s.grpcSrv.Stop()
+	s.httpSrv.Close()
+}
+
+func (s *Server) BatchWriteSpans(ctx context.Context, req *tracepb.BatchWriteSpansRequest) (*emptypb.Empty, error) {
+	for _, span := range req.Spans {
+		if _, err := s.CreateSpan(ctx, span); err != nil {
+			return nil, err
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) CreateSpan(ctx context.Context, span *tracepb.Span) (*tracepb.Span, error) {
+	matches := spanNameRe.FindStringSubmatch(span.Name)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid span name %q", span.Name)
+	}
+	traceID := strings.ToLower(matches[2])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spansByTrace[traceID] = append(s.spansByTrace[traceID], span)
+	return span, nil
+}
+
+func (s *Server) handleGetTrace(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectId")
+	traceID := strings.ToLower(r.PathValue("traceId"))
+	spans := s.Spans(traceID)
+	if len(spans) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("trace %v not found", traceID))
+		return
+	}
+	writeJSON(w, toV1Trace(projectID, traceID, spans))
+}
+
+// Supports a subset of the v1 list filter syntax: space separated label:value
+// terms, all of which must match a label on some span in the trace.
+func (s *Server) handleListTraces(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectId")
+	terms := strings.Fields(r.URL.Query().Get("filter"))
+
+	s.mu.Lock()
+	traceIDs := make([]string, 0, len(s.spansByTrace))
+	for traceID := range s.spansByTrace {
+		traceIDs = append(traceIDs, traceID)
+	}
+	s.mu.Unlock()
+	sort.Strings(traceIDs)
+
+	res := &cloudtrace.ListTracesResponse{}
+	for _, traceID := range traceIDs {
+		trace := toV1Trace(projectID, traceID, s.Spans(traceID))
+		if matchesFilter(trace, terms) {
+			res.Traces = append(res.Traces, trace)
+		}
+	}
+	writeJSON(w, res)
+}
+
+func matchesFilter(trace *cloudtrace.Trace, terms []string) bool {
+	for _, term := range terms {
+		key, value, _ := strings.Cut(strings.TrimPrefix(term, "+"), ":")
+		found := false
+		for _, span := range trace.Spans {
+			if v, ok := span.Labels[key]; ok && strings.HasPrefix(v, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func toV1Trace(projectID, traceID string, spans []*tracepb.Span) *cloudtrace.Trace {
+	trace := &cloudtrace.Trace{ProjectId: projectID, TraceId: traceID}
+	for _, span := range spans {
+		trace.Spans = append(trace.Spans, toV1Span(span))
+	}
+	return trace
+}
+
+func toV1Span(span *tracepb.Span) *cloudtrace.TraceSpan {
+	v1Span := &cloudtrace.TraceSpan{
+		SpanId:       hexToUint64(span.SpanId),
+		ParentSpanId: hexToUint64(span.ParentSpanId),
+		Name:         span.GetDisplayName().GetValue(),
+		Kind:         toV1Kind(span.SpanKind),
+		StartTime:    span.GetStartTime().AsTime().Format(time.RFC3339Nano),
+		EndTime:      span.GetEndTime().AsTime().Format(time.RFC3339Nano),
+		Labels:       map[string]string{},
+	}
+	for key, value := range span.GetAttributes().GetAttributeMap() {
+		switch v := value.Value.(type) {
+		case *tracepb.AttributeValue_StringValue:
+			v1Span.Labels[key] = v.StringValue.GetValue()
+		case *tracepb.AttributeValue_IntValue:
+			v1Span.Labels[key] = strconv.FormatInt(v.IntValue, 10)
+		case *tracepb.AttributeValue_BoolValue:
+			v1Span.Labels[key] = strconv.FormatBool(v.BoolValue)
+		}
+	}
+	if status := span.GetStatus(); status.GetCode() != 0 {
+		v1Span.Labels[StatusCodeLabel] = strconv.FormatInt(int64(status.GetCode()), 10)
+		if status.GetMessage() != "" {
+			v1Span.Labels[StatusMessageLabel] = status.GetMessage()
+		}
+	}
+	return v1Span
+}
+
+func toV1Kind(kind tracepb.Span_SpanKind) string {
+	switch kind {
+	case tracepb.Span_SERVER:
+		return "RPC_SERVER"
+	case tracepb.Span_CLIENT:
+		return "RPC_CLIENT"
+	default:
+		return "SPAN_KIND_UNSPECIFIED"
+	}
+}
+
+func hexToUint64(hex string) uint64 {
+	if hex == "" {
+		return 0
+	}
+	val, err := strconv.ParseUint(hex, 16, 64)
+	if err != nil {
+		return 0
+	}
+	return val
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Fake Cloud Trace failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": status, "message": message},
+	})
+}
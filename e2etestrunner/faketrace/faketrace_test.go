@@ -0,0 +1,113 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package faketrace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+
+	tracepb "cloud.google.com/go/trace/apiv2/tracepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/cloudtrace/v1"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	traceID      = "0af7651916cd43dd8448eb211c80319c"
+	rootSpanID   = "b7ad6b7169203331"
+	childSpanID  = "00f067aa0ba902b7"
+	spanNameTmpl = "projects/project/traces/%v/spans/%v"
+)
+
+func TestWriteAndGetTrace(t *testing.T) {
+	ctx := context.Background()
+	srv, err := Start(log.New(io.Discard, "", 0))
+	require.NoError(t, err)
+	defer srv.Close()
+
+	conn, err := grpc.NewClient(
+		fmt.Sprintf("localhost:%v", srv.GRPCPort()),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = tracepb.NewTraceServiceClient(conn).BatchWriteSpans(ctx, &tracepb.BatchWriteSpansRequest{
+		Name: "projects/project",
+		Spans: []*tracepb.Span{
+			{
+				Name:        fmt.Sprintf(spanNameTmpl, traceID, rootSpanID),
+				SpanId:      rootSpanID,
+				DisplayName: &tracepb.TruncatableString{Value: "root"},
+				SpanKind:    tracepb.Span_SERVER,
+				Attributes: &tracepb.Span_Attributes{AttributeMap: map[string]*tracepb.AttributeValue{
+					"test_id": {Value: &tracepb.AttributeValue_StringValue{StringValue: &tracepb.TruncatableString{Value: "123"}}},
+					"count":   {Value: &tracepb.AttributeValue_IntValue{IntValue: 5}},
+				}},
+			},
+			{
+				Name:         fmt.Sprintf(spanNameTmpl, traceID, childSpanID),
+				SpanId:       childSpanID,
+				ParentSpanId: rootSpanID,
+				DisplayName:  &tracepb.TruncatableString{Value: "child"},
+				Status:       &status.Status{Code: int32(code.Code_UNKNOWN), Message: "failed"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	cloudtraceService, err := cloudtrace.NewService(ctx, srv.ClientOptions()...)
+	require.NoError(t, err)
+
+	t.Run("get", func(t *testing.T) {
+		trace, err := cloudtraceService.Projects.Traces.Get("project", traceID).Context(ctx).Do()
+		require.NoError(t, err)
+		assert.Equal(t, traceID, trace.TraceId)
+		require.Len(t, trace.Spans, 2)
+
+		root, child := trace.Spans[0], trace.Spans[1]
+		assert.Equal(t, "root", root.Name)
+		assert.Equal(t, "RPC_SERVER", root.Kind)
+		assert.EqualValues(t, 0, root.ParentSpanId)
+		assert.Equal(t, map[string]string{"test_id": "123", "count": "5"}, root.Labels)
+		assert.Equal(t, "child", child.Name)
+		assert.Equal(t, root.SpanId, child.ParentSpanId)
+		assert.Equal(t, map[string]string{StatusCodeLabel: "2", StatusMessageLabel: "failed"}, child.Labels)
+	})
+
+	t.Run("get missing trace", func(t *testing.T) {
+		_, err := cloudtraceService.Projects.Traces.Get("project", "11111111111111111111111111111111").Context(ctx).Do()
+		assert.ErrorContains(t, err, "404")
+	})
+
+	t.Run("list with filter", func(t *testing.T) {
+		res, err := cloudtraceService.Projects.Traces.List("project").Filter("test_id:123").Context(ctx).Do()
+		require.NoError(t, err)
+		assert.Len(t, res.Traces, 1)
+
+		res, err = cloudtraceService.Projects.Traces.List("project").Filter("test_id:456").Context(ctx).Do()
+		require.NoError(t, err)
+		assert.Empty(t, res.Traces)
+	})
+
+	assert.Len(t, srv.Spans(traceID), 2)
+}
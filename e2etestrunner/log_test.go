@@ -0,0 +1,174 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Only build as part of e2e tests, not regular go test invocations
+//go:build e2e
+
+package e2etestrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"cloud.google.com/go/logging/logadmin"
+	"github.com/sethvargo/go-retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
+)
+
+const (
+	basicLogPayload            string = "basicLog"
+	logTraceCorrelationPayload string = "logTraceCorrelation"
+	spanIdKey                  string = "span_id"
+)
+
+func newLogAdminClient(t *testing.T, ctx context.Context) *logadmin.Client {
+	client, err := logadmin.NewClient(ctx, args.ProjectID)
+	if err != nil {
+		t.Fatalf("Failed to get logadmin client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// Calls the test server and returns the single log entry it wrote, labelled
+// with the test ID, along with the scenario response.
+func runLogScenario(t *testing.T, scenario string) (*testclient.Response, *logging.Entry) {
+	skipIfHermetic(t, "Cloud Logging")
+	skipIfUnsupported(t, scenario)
+	ctx := context.Background()
+	client := newLogAdminClient(t, ctx)
+	testID := fmt.Sprint(rand.Uint64())
+	start := time.Now()
+
+	// Call test server
+	reqCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	res, err := requestScenario(
+		reqCtx,
+		t,
+		testclient.Request{Scenario: scenario, TestID: testID},
+	)
+	checkTestScenarioResponse(t, scenario, res, err)
+
+	entries := listLogEntriesWithRetry(ctx, t, client, testID, start)
+	require.Lenf(t, entries, 1, "Expected exactly one log entry with test ID %v", testID)
+	return res, entries[0]
+}
+
+func listLogEntriesWithRetry(
+	ctx context.Context,
+	t *testing.T,
+	client *logadmin.Client,
+	testID string,
+	start time.Time,
+) []*logging.Entry {
+	filter := strings.Join([]string{
+		fmt.Sprintf("labels.%v = %q", testclient.TestID, testID),
+		// Entries may be timestamped a little before the request
+		fmt.Sprintf("timestamp >= %q", start.Add(-time.Minute).Format(time.RFC3339)),
+	}, " AND ")
+
+	var entries []*logging.Entry
+	backoff, _ := retry.NewExponential(args.LogBackoffInitial)
+	backoff = retry.WithMaxDuration(args.LogBackoffTotal, backoff)
+	err := retry.Do(ctx, backoff, func(ctx context.Context) error {
+		entries = nil
+		it := client.Entries(ctx, logadmin.Filter(filter))
+		for {
+			entry, err := it.Next()
+			if errors.Is(err, iterator.Done) {
+				break
+			}
+			if err != nil {
+				t.Logf("Retrying Entries(%v): %v", filter, err)
+				return retry.RetryableError(err)
+			}
+			entries = append(entries, entry)
+		}
+		if len(entries) == 0 {
+			err := fmt.Errorf("no log entries found yet for filter %v", filter)
+			t.Logf("Retrying Entries: %v", err)
+			return retry.RetryableError(err)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return entries
+}
+
+// Returns the log message, whether it was written as a textPayload or as the
+// message field of a jsonPayload.
+func logMessage(t *testing.T, entry *logging.Entry) string {
+	switch payload := entry.Payload.(type) {
+	case string:
+		return payload
+	case *structpb.Struct:
+		return payload.GetFields()["message"].GetStringValue()
+	default:
+		t.Fatalf("Unexpected payload type %T in log entry %v", entry.Payload, entry.InsertID)
+		return ""
+	}
+}
+
+func TestBasicLog(t *testing.T) {
+	_, entry := runLogScenario(t, "/basicLog")
+
+	assert.Equal(t, basicLogPayload, logMessage(t, entry))
+	assert.Equalf(t, logging.Warning, entry.Severity, "Expected severity %v, got %v", logging.Warning, entry.Severity)
+	checkLogResource(t, entry)
+}
+
+func TestLogTraceCorrelation(t *testing.T) {
+	res, entry := runLogScenario(t, "/logTraceCorrelation")
+	traceId := res.Headers[traceIdKey]
+	require.NotEmptyf(t, traceId, "Expected header %q but it was missing", traceIdKey)
+	spanId := res.Headers[spanIdKey]
+	require.NotEmptyf(t, spanId, "Expected header %q but it was missing", spanIdKey)
+
+	assert.Equal(t, logTraceCorrelationPayload, logMessage(t, entry))
+	assert.Equalf(t, logging.Info, entry.Severity, "Expected severity %v, got %v", logging.Info, entry.Severity)
+	// Shows up as logging.googleapis.com/trace and logging.googleapis.com/spanId
+	// when written as structured logs
+	assert.Equal(t, fmt.Sprintf("projects/%v/traces/%v", args.ProjectID, traceId), entry.Trace)
+	assert.Equal(t, spanId, entry.SpanID)
+	checkLogResource(t, entry)
+}
+
+// Checks the monitored resource the log entry was written with on each
+// platform.
+func checkLogResource(t *testing.T, entry *logging.Entry) {
+	if args.Local != nil {
+		return
+	}
+	require.NotNil(t, entry.Resource, "Log entry is missing a monitored resource")
+
+	expect := declaredResources(t).Log
+	require.Equal(t, expect.Type, entry.Resource.Type)
+	for _, label := range expect.Labels {
+		t.Run(fmt.Sprintf("Log resource has label %v", label), func(t *testing.T) {
+			assert.NotEmptyf(t, entry.Resource.Labels[label], "Missing monitored resource label %v", label)
+		})
+	}
+}
@@ -19,9 +19,14 @@ package e2etestrunner
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/report"
 	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
 
 	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
@@ -30,6 +35,10 @@ import (
 var (
 	args             e2etesting.Args
 	testServerClient *testclient.Client
+	// nil if the test server doesn't implement the /capabilities scenario
+	serverCapabilities *testclient.ServerCapabilities
+	// nil without --report-dir
+	reportRecorder *report.Recorder
 )
 
 func TestMain(m *testing.M) {
@@ -38,44 +47,93 @@ func TestMain(m *testing.M) {
 		return
 	}
 
-	var setupFunc e2etesting.SetupFunc
-	switch {
-	case args.Local != nil:
-		setupFunc = SetupLocal
-	case args.Gce != nil:
-		setupFunc = SetupGce
-	case args.Gke != nil:
-		setupFunc = SetupGke
-	case args.CloudRun != nil:
-		setupFunc = SetupCloudRun
-	case args.CloudFunctionsGen2 != nil:
-		setupFunc = SetupCloudFunctionsGen2
-	case args.Gae != nil:
-		setupFunc = SetupGae
-	case args.GaeStandard != nil:
-		setupFunc = SetupGaeStandard
+	reportRecorder = args.NewReportRecorder()
+	teardown := e2etesting.NewTeardown(ctx, logger)
+	defer teardown.RunOnExit()
+	ctx = teardown.Context()
+
+	if args.Matrix != nil {
+		setupMatrix(ctx, m, teardown, logger)
+	} else {
+		setupSinglePlatform(ctx, teardown, logger)
 	}
-	client, cleanup, err := setupFunc(ctx, &args, logger)
 
-	defer cleanup()
+	// Run tests
+	e2etesting.RunTests(m, &args, reportRecorder, logger)
+}
+
+func setupSinglePlatform(ctx context.Context, teardown *e2etesting.Teardown, logger *log.Logger) {
+	err := teardown.Setup(fmt.Sprintf("test run %v", args.TestRunID), func(ctx context.Context) (e2etesting.Cleanup, error) {
+		client, cleanup, err := setupFuncFor(&args)(ctx, &args, logger)
+		// set global client
+		testServerClient = client
+		return cleanup, err
+	})
 	if err != nil {
 		logger.Panic(err)
 	}
 
-	// set global client
-	testServerClient = client
+	serverCapabilities, err = waitForTestServer(ctx, &args, testServerClient, logger)
+	if err != nil {
+		logger.Panic(err)
+	}
+}
+
+func setupFuncFor(a *e2etesting.Args) e2etesting.SetupFunc {
+	switch {
+	case a.Local != nil:
+		return SetupLocal
+	case a.Gce != nil:
+		return SetupGce
+	case a.Gke != nil:
+		return SetupGke
+	case a.CloudRun != nil:
+		return SetupCloudRun
+	case a.CloudFunctionsGen2 != nil:
+		return SetupCloudFunctionsGen2
+	case a.Gae != nil:
+		return SetupGae
+	case a.GaeStandard != nil:
+		return SetupGaeStandard
+	}
+	return nil
+}
 
-	// wait for instrumented test server to be healthy
-	logger.Printf("Waiting for health check on pub/sub channel (will timeout after %v)\n", args.HealthCheckTimeout)
-	cctx, cancel := context.WithTimeout(ctx, args.HealthCheckTimeout)
+// Waits for the instrumented test server to be healthy, then asks for its
+// capabilities
+func waitForTestServer(
+	ctx context.Context,
+	a *e2etesting.Args,
+	client *testclient.Client,
+	logger *log.Logger,
+) (*testclient.ServerCapabilities, error) {
+	logger.Printf("Waiting for health check (will timeout after %v)\n", a.HealthCheckTimeout)
+	cctx, cancel := context.WithTimeout(ctx, a.HealthCheckTimeout)
 	defer cancel()
-	err = testServerClient.WaitForHealth(cctx, logger)
+	if err := client.WaitForHealth(cctx, logger); err != nil {
+		return nil, err
+	}
+
+	capabilities, err := client.Capabilities(cctx)
 	if err != nil {
-		logger.Panic(err)
+		return nil, err
 	}
+	logCapabilities(logger, capabilities)
+	return capabilities, nil
+}
 
-	// Run tests
-	logger.Print(e2etesting.BeginOutputArt)
-	m.Run()
-	logger.Print(e2etesting.EndOutputArt)
+func logCapabilities(logger *log.Logger, capabilities *testclient.ServerCapabilities) {
+	if capabilities == nil {
+		logger.Printf("Test server does not implement %v, unsupported scenarios will be skipped per test\n", testclient.Capabilities)
+		return
+	}
+	logger.Printf("Test server implements scenarios: %v\n", strings.Join(capabilities.Scenarios, ", "))
+	keys := make([]string, 0, len(capabilities.Attributes))
+	for k := range capabilities.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		logger.Printf("Test server %v: %v\n", k, capabilities.Attributes[k])
+	}
 }
@@ -0,0 +1,149 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Only build as part of e2e tests, not regular go test invocations
+//go:build e2e
+
+package e2etestrunner
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
+)
+
+// A platform deployed by the matrix subcommand
+type matrixPlatform struct {
+	args         *e2etesting.Args
+	client       *testclient.Client
+	capabilities *testclient.ServerCapabilities
+	// Why the platform couldn't be set up, reported by its subtest
+	err error
+}
+
+// Set by TestMain for the matrix subcommand
+var matrixPlatforms []*matrixPlatform
+
+// The top level tests TestMatrix runs against each platform, all of the
+// binary's other tests. Set by setupMatrix.
+var scenarioTests []testing.InternalTest
+
+// Returns the tests m runs. testing.M doesn't export them, so this reads its
+// unexported tests field.
+func testsOf(m *testing.M) []testing.InternalTest {
+	field := reflect.ValueOf(m).Elem().FieldByName("tests")
+	if !field.IsValid() || field.Type() != reflect.TypeOf([]testing.InternalTest(nil)) {
+		panic("testing.M has no tests field of type []testing.InternalTest anymore, update testsOf")
+	}
+	return *(*[]testing.InternalTest)(unsafe.Pointer(field.UnsafeAddr()))
+}
+
+// Sets up all of the matrix subcommand's platforms concurrently, and restricts
+// the tests to TestMatrix. A platform which fails to set up doesn't stop the
+// others, its subtest fails instead.
+func setupMatrix(ctx context.Context, m *testing.M, teardown *e2etesting.Teardown, logger *log.Logger) {
+	platformArgs, err := args.Matrix.PlatformArgs(&args)
+	if err != nil {
+		logger.Panic(err)
+	}
+	for _, test := range testsOf(m) {
+		if test.Name != "TestMatrix" {
+			scenarioTests = append(scenarioTests, test)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, a := range platformArgs {
+		platform := &matrixPlatform{args: a}
+		matrixPlatforms = append(matrixPlatforms, platform)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger := log.New(os.Stdout, fmt.Sprintf("TestMain[%v]: ", a.TestRunID), log.LstdFlags|log.Lshortfile)
+			platform.err = setupMatrixPlatform(ctx, teardown, platform, logger)
+			if platform.err != nil {
+				logger.Printf("Failed to set up %v: %v\n", a.Platform(), platform.err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	run := "^TestMatrix$"
+	if userRun := flag.Lookup("test.run").Value.String(); userRun != "" {
+		// Apply it to the scenario tests under each platform's subtest
+		run += "//" + userRun
+	}
+	flag.Set("test.run", run)
+}
+
+func setupMatrixPlatform(
+	ctx context.Context,
+	teardown *e2etesting.Teardown,
+	platform *matrixPlatform,
+	logger *log.Logger,
+) error {
+	if platform.args.Local != nil && platform.args.Local.Hermetic {
+		// The fake Cloud Trace backend is global to the test binary
+		return errors.New("local --hermetic is not supported with the matrix subcommand")
+	}
+
+	err := teardown.Setup(fmt.Sprintf("test run %v", platform.args.TestRunID), func(ctx context.Context) (e2etesting.Cleanup, error) {
+		client, cleanup, err := setupFuncFor(platform.args)(ctx, platform.args, logger)
+		platform.client = client
+		return cleanup, err
+	})
+	if err != nil {
+		return err
+	}
+	platform.capabilities, err = waitForTestServer(ctx, platform.args, platform.client, logger)
+	return err
+}
+
+func TestMatrix(t *testing.T) {
+	if matrixPlatforms == nil {
+		t.Skip("Only runs with the matrix subcommand")
+	}
+
+	// The scenario tests use the globals, so the platforms take turns
+	savedArgs, savedClient, savedCapabilities := args, testServerClient, serverCapabilities
+	defer func() {
+		args, testServerClient, serverCapabilities = savedArgs, savedClient, savedCapabilities
+	}()
+
+	for _, platform := range matrixPlatforms {
+		t.Run(platform.args.Platform(), func(t *testing.T) {
+			if platform.err != nil {
+				t.Fatalf("Failed to set up test run %v: %v", platform.args.TestRunID, platform.err)
+			}
+			reportRecorder.SetPlatform(t.Name(), platform.args.ReportPlatform())
+			args = *platform.args
+			testServerClient = platform.client
+			serverCapabilities = platform.capabilities
+
+			for _, test := range scenarioTests {
+				t.Run(test.Name, test.F)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Only build as part of e2e tests, not regular go test invocations
+//go:build e2e
+
+package e2etestrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	monitoringpb "cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/sethvargo/go-retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+	"google.golang.org/genproto/googleapis/api/metric"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
+)
+
+const (
+	basicMetricType             string = "workload.googleapis.com/basicMetric"
+	histogramMetricType         string = "workload.googleapis.com/histogramMetric"
+	metricResourceDetectionType string = "workload.googleapis.com/metricResourceDetection"
+)
+
+func newMetricClient(t *testing.T, ctx context.Context) *monitoring.MetricClient {
+	client, err := monitoring.NewMetricClient(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get metric client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// Calls the test server and returns the single time series it wrote for the
+// metric type, labelled with the test ID.
+func runMetricScenario(
+	t *testing.T,
+	scenario string,
+	metricType string,
+) (*monitoring.MetricClient, *monitoringpb.TimeSeries) {
+	skipIfHermetic(t, "Cloud Monitoring")
+	skipIfUnsupported(t, scenario)
+	ctx := context.Background()
+	client := newMetricClient(t, ctx)
+	testID := fmt.Sprint(rand.Uint64())
+	start := time.Now()
+
+	// Call test server
+	reqCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	res, err := requestScenario(
+		reqCtx,
+		t,
+		testclient.Request{Scenario: scenario, TestID: testID},
+	)
+	checkTestScenarioResponse(t, scenario, res, err)
+
+	series := listTimeSeriesWithRetry(ctx, t, client, metricType, testID, start)
+	require.Lenf(t, series, 1, "Expected exactly one time series for %v with test ID %v", metricType, testID)
+	return client, series[0]
+}
+
+func listTimeSeriesWithRetry(
+	ctx context.Context,
+	t *testing.T,
+	client *monitoring.MetricClient,
+	metricType string,
+	testID string,
+	start time.Time,
+) []*monitoringpb.TimeSeries {
+	filter := strings.Join([]string{
+		fmt.Sprintf("metric.type = %q", metricType),
+		fmt.Sprintf("metric.labels.%v = %q", testclient.TestID, testID),
+	}, " AND ")
+
+	var series []*monitoringpb.TimeSeries
+	backoff, _ := retry.NewExponential(args.MetricBackoffInitial)
+	backoff = retry.WithMaxDuration(args.MetricBackoffTotal, backoff)
+	err := retry.Do(ctx, backoff, func(ctx context.Context) error {
+		series = nil
+		it := client.ListTimeSeries(ctx, &monitoringpb.ListTimeSeriesRequest{
+			Name:   "projects/" + args.ProjectID,
+			Filter: filter,
+			Interval: &monitoringpb.TimeInterval{
+				// Cumulative points may start a little before the request
+				StartTime: timestamppb.New(start.Add(-time.Minute)),
+				EndTime:   timestamppb.New(time.Now()),
+			},
+			View: monitoringpb.ListTimeSeriesRequest_FULL,
+		})
+		for {
+			ts, err := it.Next()
+			if errors.Is(err, iterator.Done) {
+				break
+			}
+			if err != nil {
+				t.Logf("Retrying ListTimeSeries(%v): %v", filter, err)
+				return retry.RetryableError(err)
+			}
+			series = append(series, ts)
+		}
+		if len(series) == 0 {
+			err := fmt.Errorf("no time series found yet for filter %v", filter)
+			t.Logf("Retrying ListTimeSeries: %v", err)
+			return retry.RetryableError(err)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return series
+}
+
+// Checks the metric descriptor the exporter created for the metric type.
+func checkMetricDescriptor(
+	t *testing.T,
+	client *monitoring.MetricClient,
+	metricType string,
+	expectKind metric.MetricDescriptor_MetricKind,
+	expectValueType metric.MetricDescriptor_ValueType,
+) {
+	descriptor, err := client.GetMetricDescriptor(context.Background(), &monitoringpb.GetMetricDescriptorRequest{
+		Name: fmt.Sprintf("projects/%v/metricDescriptors/%v", args.ProjectID, metricType),
+	})
+	require.NoErrorf(t, err, "Failed to get metric descriptor for %v", metricType)
+	assert.Equal(t, expectKind, descriptor.MetricKind)
+	assert.Equal(t, expectValueType, descriptor.ValueType)
+
+	var labelKeys []string
+	for _, label := range descriptor.Labels {
+		labelKeys = append(labelKeys, label.Key)
+	}
+	assert.Containsf(t, labelKeys, testclient.TestID, "Metric descriptor is missing label %v", testclient.TestID)
+}
+
+func TestBasicMetric(t *testing.T) {
+	client, ts := runMetricScenario(t, "/basicMetric", basicMetricType)
+
+	checkMetricDescriptor(t, client, basicMetricType, metric.MetricDescriptor_CUMULATIVE, metric.MetricDescriptor_INT64)
+	assert.Equal(t, metric.MetricDescriptor_CUMULATIVE, ts.MetricKind)
+	assert.Equal(t, metric.MetricDescriptor_INT64, ts.ValueType)
+	require.NotEmpty(t, ts.Points)
+	assert.EqualValues(t, 1, ts.Points[0].Value.GetInt64Value())
+}
+
+func TestHistogramMetric(t *testing.T) {
+	client, ts := runMetricScenario(t, "/histogramMetric", histogramMetricType)
+
+	checkMetricDescriptor(t, client, histogramMetricType, metric.MetricDescriptor_CUMULATIVE, metric.MetricDescriptor_DISTRIBUTION)
+	assert.Equal(t, metric.MetricDescriptor_CUMULATIVE, ts.MetricKind)
+	assert.Equal(t, metric.MetricDescriptor_DISTRIBUTION, ts.ValueType)
+	require.NotEmpty(t, ts.Points)
+	distribution := ts.Points[0].Value.GetDistributionValue()
+	require.NotNil(t, distribution)
+	assert.EqualValues(t, 3, distribution.Count, "Expected the 3 recorded measurements")
+	assert.NotNil(t, distribution.BucketOptions, "Expected bucket options to be set")
+}
+
+func TestMetricResourceDetection(t *testing.T) {
+	if args.Local != nil {
+		t.Skip("Local runs do not need to test resource detection")
+	}
+	expect := declaredResources(t).Metric
+	_, ts := runMetricScenario(t, "/metricResourceDetection", metricResourceDetectionType)
+
+	require.Equal(t, expect.Type, ts.Resource.Type)
+	for _, label := range expect.Labels {
+		t.Run(fmt.Sprintf("Resource has label %v", label), func(t *testing.T) {
+			assert.NotEmptyf(t, ts.Resource.Labels[label], "Missing monitored resource label %v", label)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Only build as part of e2e tests, not regular go test invocations
+//go:build e2e
+
+package e2etestrunner
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cloudtrace "google.golang.org/api/cloudtrace/v1"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
+)
+
+const (
+	w3cPropagatorSpanName              string = "w3cPropagator"
+	baggagePropagatorSpanName          string = "baggagePropagator"
+	cloudTraceOneWayPropagatorSpanName string = "cloudTraceOneWayPropagator"
+	traceparentName                    string = "traceparent"
+	tracestateName                     string = "tracestate"
+	baggageName                        string = "baggage"
+	baggageKey                         string = "e2e_baggage"
+	sampledFlags                       string = "01"
+	notSampledFlags                    string = "00"
+)
+
+var traceparentRe = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// A parsed W3C traceparent header
+type traceparent struct {
+	traceId string
+	spanId  string
+	flags   string
+}
+
+func (tp traceparent) String() string {
+	return fmt.Sprintf("00-%v-%v-%v", tp.traceId, tp.spanId, tp.flags)
+}
+
+func newTraceparent(t *testing.T, flags string) traceparent {
+	traceId, err := e2etesting.RandomHex(16)
+	require.NoError(t, err)
+	spanId, err := e2etesting.RandomHex(8)
+	require.NoError(t, err)
+	return traceparent{traceId: traceId, spanId: spanId, flags: flags}
+}
+
+func parseTraceparent(t *testing.T, value string) traceparent {
+	match := traceparentRe.FindStringSubmatch(value)
+	require.NotNilf(t, match, "Invalid %v %q", traceparentName, value)
+	return traceparent{traceId: match[1], spanId: match[2], flags: match[3]}
+}
+
+// Cloud Trace v1 span IDs are decimal
+func spanIdToDec(t *testing.T, spanIdHex string) uint64 {
+	dec, err := strconv.ParseUint(spanIdHex, 16, 64)
+	require.NoError(t, err)
+	return dec
+}
+
+// Calls the test server with the propagation headers and returns the response.
+func callPropagatorScenario(t *testing.T, scenario string, headers map[string]string) *testclient.Response {
+	ctx := context.Background()
+	testID := fmt.Sprint(rand.Uint64())
+
+	reqCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	res, err := requestScenario(
+		reqCtx,
+		t,
+		testclient.Request{Scenario: scenario, TestID: testID, Headers: headers},
+	)
+	checkTestScenarioResponse(t, scenario, res, err)
+	return res
+}
+
+// Gets the single span the scenario recorded in the trace from the backend and
+// checks its name and parent.
+func getPropagatedSpan(
+	t *testing.T,
+	traceId string,
+	expectName string,
+	expectParentSpanId uint64,
+) *cloudtrace.TraceSpan {
+	ctx := context.Background()
+	trace := getTraceWithRetry(ctx, t, newTraceService(t, ctx), traceId)
+	require.Lenf(t, trace.Spans, 1, "Expected exactly one span in trace %v", traceId)
+
+	span := trace.Spans[0]
+	require.Equalf(t, expectName, span.Name, `Expected span name %v, got "%v"`, expectName, span.Name)
+	require.Equalf(
+		t,
+		expectParentSpanId,
+		span.ParentSpanId,
+		`Expected parent span ID %v, got "%v"`,
+		expectParentSpanId,
+		span.ParentSpanId,
+	)
+	return span
+}
+
+// Checks the traceparent the test server would propagate downstream continues
+// the trace from the span it recorded.
+func checkOutgoingTraceparent(t *testing.T, res *testclient.Response, expectTraceId string, expectFlags string) traceparent {
+	value, ok := res.Headers[traceparentName]
+	require.Truef(t, ok, "Expected header %q but it was missing", traceparentName)
+	outgoing := parseTraceparent(t, value)
+	assert.Equalf(t, expectTraceId, outgoing.traceId, "Outgoing %v has the wrong trace ID", traceparentName)
+	assert.Equalf(t, expectFlags, outgoing.flags, "Outgoing %v has the wrong flags", traceparentName)
+	return outgoing
+}
+
+func TestW3CPropagator(t *testing.T) {
+	scenario := "/w3cPropagator"
+	skipIfUnsupported(t, scenario)
+	incoming := newTraceparent(t, sampledFlags)
+	tracestate := "e2e=" + fmt.Sprint(rand.Uint32())
+
+	res := callPropagatorScenario(t, scenario, map[string]string{
+		traceparentName: incoming.String(),
+		tracestateName:  tracestate,
+	})
+
+	outgoing := checkOutgoingTraceparent(t, res, incoming.traceId, sampledFlags)
+	assert.Equalf(t, tracestate, res.Headers[tracestateName], "Expected %v to be propagated", tracestateName)
+	span := getPropagatedSpan(t, incoming.traceId, w3cPropagatorSpanName, spanIdToDec(t, incoming.spanId))
+	assert.Equalf(t, spanIdToDec(t, outgoing.spanId), span.SpanId, "Outgoing %v should have the recorded span's ID", traceparentName)
+}
+
+func TestW3CPropagatorNotSampled(t *testing.T) {
+	scenario := "/w3cPropagator"
+	skipIfUnsupported(t, scenario)
+	incoming := newTraceparent(t, notSampledFlags)
+
+	res := callPropagatorScenario(t, scenario, map[string]string{traceparentName: incoming.String()})
+
+	// Nothing is recorded for unsampled spans, so only the sampling decision
+	// the test server propagates can be checked
+	outgoing := checkOutgoingTraceparent(t, res, incoming.traceId, notSampledFlags)
+	assert.NotEqualf(t, incoming.spanId, outgoing.spanId, "Expected the test server to create a new span")
+}
+
+func TestBaggagePropagator(t *testing.T) {
+	scenario := "/baggagePropagator"
+	skipIfUnsupported(t, scenario)
+	incoming := newTraceparent(t, sampledFlags)
+	baggageValue := fmt.Sprint(rand.Uint64())
+
+	res := callPropagatorScenario(t, scenario, map[string]string{
+		traceparentName: incoming.String(),
+		baggageName:     fmt.Sprintf("%v=%v", baggageKey, baggageValue),
+	})
+
+	checkOutgoingTraceparent(t, res, incoming.traceId, sampledFlags)
+	t.Run("Baggage is propagated", func(t *testing.T) {
+		assert.Containsf(
+			t,
+			strings.Split(res.Headers[baggageName], ","),
+			fmt.Sprintf("%v=%v", baggageKey, baggageValue),
+			"Expected outgoing %v to contain the incoming member", baggageName,
+		)
+	})
+	span := getPropagatedSpan(t, incoming.traceId, baggagePropagatorSpanName, spanIdToDec(t, incoming.spanId))
+	t.Run(fmt.Sprintf("Span has label %v", baggageKey), func(t *testing.T) {
+		assert.Equalf(t, baggageValue, span.Labels[baggageKey], "Expected baggage member %v as a span label", baggageKey)
+	})
+}
+
+func TestCloudTraceOneWayPropagator(t *testing.T) {
+	scenario := "/cloudTraceOneWayPropagator"
+	skipIfUnsupported(t, scenario)
+	traceIdHex, err := e2etesting.RandomHex(16)
+	require.NoError(t, err)
+	parentSpanIdDec := rand.Uint64()
+
+	res := callPropagatorScenario(t, scenario, map[string]string{
+		xCloudTraceContextName: fmt.Sprintf("%v/%v;o=1", traceIdHex, parentSpanIdDec),
+	})
+
+	// One-way: X-Cloud-Trace-Context is only extracted, traceparent is what
+	// gets propagated downstream
+	outgoing := checkOutgoingTraceparent(t, res, traceIdHex, sampledFlags)
+	for key := range res.Headers {
+		assert.Falsef(t, strings.EqualFold(key, xCloudTraceContextName), "Expected %v not to be propagated", xCloudTraceContextName)
+	}
+	span := getPropagatedSpan(t, traceIdHex, cloudTraceOneWayPropagatorSpanName, parentSpanIdDec)
+	assert.Equalf(t, spanIdToDec(t, outgoing.spanId), span.SpanId, "Outgoing %v should have the recorded span's ID", traceparentName)
+}
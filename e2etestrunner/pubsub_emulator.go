@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2etestrunner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"cloud.google.com/go/pubsub"
+	pb "cloud.google.com/go/pubsub/apiv1/pubsubpb"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
+	"google.golang.org/grpc"
+)
+
+const pubsubEmulatorHostEnv = "PUBSUB_EMULATOR_HOST"
+
+// Starts an in-process Pub/Sub emulator and creates the same request/response
+// topics and subscriptions as tf/modules/pubsub. Returns the port the emulator
+// is listening on (on all interfaces, so the test server container can reach
+// it) and a cleanup function to shut it down.
+//
+// As a side effect, sets PUBSUB_EMULATOR_HOST for this process so that
+// testclient connects to the emulator.
+func startPubsubEmulator(
+	ctx context.Context,
+	projectID string,
+	testRunID string,
+	logger *log.Logger,
+) (*setuptf.PubsubInfo, int, e2etesting.Cleanup, error) {
+	// pstest only listens on localhost, so serve its fake over a second
+	// listener which is reachable from other containers
+	pstestSrv := pstest.NewServer()
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		pstestSrv.Close()
+		return nil, 0, e2etesting.NoopCleanup, err
+	}
+	grpcSrv := grpc.NewServer()
+	pb.RegisterPublisherServer(grpcSrv, &pstestSrv.GServer)
+	pb.RegisterSubscriberServer(grpcSrv, &pstestSrv.GServer)
+	go func() {
+		if err := grpcSrv.Serve(lis); err != nil {
+			logger.Printf("Pub/Sub emulator stopped serving: %v\n", err)
+		}
+	}()
+	cleanup := func() {
+		grpcSrv.Stop()
+		pstestSrv.Close()
+	}
+
+	port := lis.Addr().(*net.TCPAddr).Port
+	emulatorHost := fmt.Sprintf("localhost:%v", port)
+	logger.Printf("Started Pub/Sub emulator on %v\n", emulatorHost)
+	if err := os.Setenv(pubsubEmulatorHostEnv, emulatorHost); err != nil {
+		return nil, 0, cleanup, err
+	}
+
+	client, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, 0, cleanup, err
+	}
+	defer client.Close()
+
+	// Use the same names as tf/modules/pubsub
+	pubsubInfo := &setuptf.PubsubInfo{
+		RequestTopic: setuptf.TopicInfo{
+			TopicName:        "request-" + testRunID,
+			SubscriptionName: "request-" + testRunID + "-pull",
+		},
+		ResponseTopic: setuptf.TopicInfo{
+			TopicName:        "response-" + testRunID,
+			SubscriptionName: "response-" + testRunID + "-pull",
+		},
+	}
+	for _, topicInfo := range []setuptf.TopicInfo{pubsubInfo.RequestTopic, pubsubInfo.ResponseTopic} {
+		topic, err := client.CreateTopic(ctx, topicInfo.TopicName)
+		if err != nil {
+			return nil, 0, cleanup, err
+		}
+		_, err = client.CreateSubscription(ctx, topicInfo.SubscriptionName, pubsub.SubscriptionConfig{
+			Topic: topic,
+		})
+		if err != nil {
+			return nil, 0, cleanup, err
+		}
+	}
+	return pubsubInfo, port, cleanup, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Offline unit tests, excluded from the e2e test binary
+//go:build !e2e
+
+package e2etestrunner
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/code"
+)
+
+func TestPubsubEmulator(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	defer os.Unsetenv(pubsubEmulatorHostEnv)
+
+	pubsubInfo, port, cleanup, err := startPubsubEmulator(ctx, "project", "abc123", log.New(io.Discard, "", 0))
+	defer cleanup()
+	require.NoError(t, err)
+	assert.NotZero(t, port)
+	assert.Equal(t, "request-abc123", pubsubInfo.RequestTopic.TopicName)
+	assert.Equal(t, "response-abc123-pull", pubsubInfo.ResponseTopic.SubscriptionName)
+
+	// Act as the test server on the other end of the topics
+	serverClient, err := pubsub.NewClient(ctx, "project")
+	require.NoError(t, err)
+	defer serverClient.Close()
+	responseTopic := serverClient.Topic(pubsubInfo.ResponseTopic.TopicName)
+	go serverClient.Subscription(pubsubInfo.RequestTopic.SubscriptionName).Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+		msg.Ack()
+		responseTopic.Publish(ctx, &pubsub.Message{Attributes: map[string]string{
+			testclient.TestID:     msg.Attributes[testclient.TestID],
+			testclient.StatusCode: strconv.Itoa(int(code.Code_OK)),
+		}})
+	})
+
+	client, err := testclient.New(ctx, "project", pubsubInfo)
+	require.NoError(t, err)
+	res, err := client.Request(ctx, testclient.Request{Scenario: "/basicTrace", TestID: "123"})
+	require.NoError(t, err)
+	assert.Equal(t, code.Code_OK, res.StatusCode)
+}
@@ -25,6 +25,13 @@ import (
 
 const cloudFunctionTfDir string = "tf/cloud-functions-gen2"
 
+// The outputs of tf/cloud-functions-gen2
+type cloudFunctionOutputs struct {
+	PubsubInfo   setuptf.PubsubInfo `json:"pubsub_info"`
+	FunctionName string             `json:"function_name"`
+	FunctionURL  string             `json:"function_url"`
+}
+
 // SetupCloudFunctionsGen2 sets up the instrumented test server to run in Cloud Functions (2nd Gen).
 // Creates a new Cloud Function and runs the specified source zip containing the code that needs to be run.
 // The returned cleanup function tears down everything.
@@ -33,7 +40,7 @@ func SetupCloudFunctionsGen2(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (*testclient.Client, e2etesting.Cleanup, error) {
-	pubsubInfo, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
@@ -48,7 +55,12 @@ func SetupCloudFunctionsGen2(
 	if err != nil {
 		return nil, cleanupTf, err
 	}
+	tfOutputs := cloudFunctionOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return nil, cleanupTf, err
+	}
+	logger.Printf("Test server running in Cloud Function %v at %v\n", tfOutputs.FunctionName, tfOutputs.FunctionURL)
 
-	client, err := testclient.New(ctx, args.ProjectID, pubsubInfo)
+	client, err := testclient.New(ctx, args.ProjectID, &tfOutputs.PubsubInfo)
 	return client, cleanupTf, err
 }
@@ -25,6 +25,13 @@ import (
 
 const cloudRunTfDir string = "tf/cloud-run"
 
+// The outputs of tf/cloud-run
+type cloudRunOutputs struct {
+	PubsubInfo  setuptf.PubsubInfo `json:"pubsub_info"`
+	ServiceName string             `json:"service_name"`
+	ServiceURL  string             `json:"service_url"`
+}
+
 // SetupCloudRun sets up the instrumented test server to run in Cloud Run.
 // Creates a new service and runs the specified container image as a revision.
 // The returned cleanup function tears down everything.
@@ -33,7 +40,7 @@ func SetupCloudRun(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (*testclient.Client, e2etesting.Cleanup, error) {
-	pubsubInfo, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
@@ -46,7 +53,12 @@ func SetupCloudRun(
 	if err != nil {
 		return nil, cleanupTf, err
 	}
+	tfOutputs := cloudRunOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return nil, cleanupTf, err
+	}
+	logger.Printf("Test server running in Cloud Run service %v at %v\n", tfOutputs.ServiceName, tfOutputs.ServiceURL)
 
-	client, err := testclient.New(ctx, args.ProjectID, pubsubInfo)
+	client, err := testclient.New(ctx, args.ProjectID, &tfOutputs.PubsubInfo)
 	return client, cleanupTf, err
 }
@@ -0,0 +1,137 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Offline unit tests, excluded from the e2e test binary
+//go:build !e2e
+
+package e2etestrunner
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf/fakeprovisioner"
+)
+
+func TestSetupFuncs(t *testing.T) {
+	cases := []struct {
+		name       string
+		setupFunc  e2etesting.SetupFunc
+		args       e2etesting.Args
+		expectDir  string
+		expectVars map[string]string
+	}{
+		{
+			name:       "gce",
+			setupFunc:  SetupGce,
+			args:       e2etesting.Args{Gce: &e2etesting.GceCmd{CmdWithImage: e2etesting.CmdWithImage{Image: "foo:latest"}}},
+			expectDir:  gceTfDir,
+			expectVars: map[string]string{"image": "foo:latest"},
+		},
+		{
+			name:       "gke",
+			setupFunc:  SetupGke,
+			args:       e2etesting.Args{Gke: &e2etesting.GkeCmd{CmdWithImage: e2etesting.CmdWithImage{Image: "foo:latest"}}},
+			expectDir:  gkeTfDir,
+			expectVars: map[string]string{"image": "foo:latest"},
+		},
+		{
+			name:       "cloud-run",
+			setupFunc:  SetupCloudRun,
+			args:       e2etesting.Args{CloudRun: &e2etesting.CloudRunCmd{CmdWithImage: e2etesting.CmdWithImage{Image: "foo:latest"}}},
+			expectDir:  cloudRunTfDir,
+			expectVars: map[string]string{"image": "foo:latest"},
+		},
+		{
+			name:      "cloud-functions-gen2",
+			setupFunc: SetupCloudFunctionsGen2,
+			args: e2etesting.Args{CloudFunctionsGen2: &e2etesting.CloudFunctionsGen2Cmd{
+				Runtime:        "go123",
+				EntryPoint:     "HandleCloudFunction",
+				FunctionSource: "/function-source.zip",
+			}},
+			expectDir: cloudFunctionTfDir,
+			expectVars: map[string]string{
+				"runtime":        "go123",
+				"entrypoint":     "HandleCloudFunction",
+				"functionsource": "/function-source.zip",
+			},
+		},
+		{
+			name:      "gae",
+			setupFunc: SetupGae,
+			args: e2etesting.Args{Gae: &e2etesting.GaeCmd{
+				CmdWithImage: e2etesting.CmdWithImage{Image: "foo:latest"},
+				Runtime:      "go",
+			}},
+			expectDir:  gaeTfDir,
+			expectVars: map[string]string{"image": "foo:latest", "runtime": "go"},
+		},
+		{
+			name:      "gae-standard",
+			setupFunc: SetupGaeStandard,
+			args: e2etesting.Args{GaeStandard: &e2etesting.GaeStandardCmd{
+				Runtime:    "go123",
+				AppSource:  "/appsource.zip",
+				Entrypoint: "./server",
+			}},
+			expectDir: gaeStandardTfDir,
+			expectVars: map[string]string{
+				"runtime":    "go123",
+				"appsource":  "/appsource.zip",
+				"entrypoint": "./server",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			defer os.Unsetenv(pubsubEmulatorHostEnv)
+			logger := log.New(io.Discard, "", 0)
+
+			// The Pub/Sub client needs somewhere to connect to
+			pubsubInfo, _, cleanupEmulator, err := startPubsubEmulator(ctx, "project", "abc123", logger)
+			defer cleanupEmulator()
+			require.NoError(t, err)
+			fake := fakeprovisioner.New(pubsubInfo)
+			fakeprovisioner.Install(t, fake)
+
+			tc.args.ProjectID = "project"
+			tc.args.TestRunID = "abc123"
+			client, cleanup, err := tc.setupFunc(ctx, &tc.args, logger)
+			require.NoError(t, err)
+			assert.NotNil(t, client)
+			assert.Equal(t, []setuptf.ApplyRequest{{
+				ProjectID: "project",
+				TestRunID: "abc123",
+				Dir:       tc.expectDir,
+				Vars:      tc.expectVars,
+			}}, fake.Applied())
+
+			cleanup()
+			assert.Len(t, fake.Destroyed(), 1)
+		})
+	}
+}
@@ -25,12 +25,19 @@ import (
 
 const gaeTfDir string = "tf/gae"
 
+// The outputs of tf/gae
+type gaeOutputs struct {
+	PubsubInfo  setuptf.PubsubInfo `json:"pubsub_info"`
+	ServiceName string             `json:"service_name"`
+	VersionID   string             `json:"version_id"`
+}
+
 func SetupGae(
 	ctx context.Context,
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (*testclient.Client, e2etesting.Cleanup, error) {
-	pubsubInfo, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
@@ -44,7 +51,18 @@ func SetupGae(
 	if err != nil {
 		return nil, cleanupTf, err
 	}
+	tfOutputs := gaeOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return nil, cleanupTf, err
+	}
+	logger.Printf(
+		"Test server running in App Engine service %v version %v: https://console.cloud.google.com/appengine/versions?serviceId=%v&project=%v\n",
+		tfOutputs.ServiceName,
+		tfOutputs.VersionID,
+		tfOutputs.ServiceName,
+		args.ProjectID,
+	)
 
-	client, err := testclient.New(ctx, args.ProjectID, pubsubInfo)
+	client, err := testclient.New(ctx, args.ProjectID, &tfOutputs.PubsubInfo)
 	return client, cleanupTf, err
 }
@@ -25,12 +25,19 @@ import (
 
 const gaeStandardTfDir string = "tf/gae-standard"
 
+// The outputs of tf/gae-standard
+type gaeStandardOutputs struct {
+	PubsubInfo  setuptf.PubsubInfo `json:"pubsub_info"`
+	ServiceName string             `json:"service_name"`
+	VersionID   string             `json:"version_id"`
+}
+
 func SetupGaeStandard(
 	ctx context.Context,
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (*testclient.Client, e2etesting.Cleanup, error) {
-	pubsubInfo, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
@@ -45,7 +52,18 @@ func SetupGaeStandard(
 	if err != nil {
 		return nil, cleanupTf, err
 	}
+	tfOutputs := gaeStandardOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return nil, cleanupTf, err
+	}
+	logger.Printf(
+		"Test server running in App Engine service %v version %v: https://console.cloud.google.com/appengine/versions?serviceId=%v&project=%v\n",
+		tfOutputs.ServiceName,
+		tfOutputs.VersionID,
+		tfOutputs.ServiceName,
+		args.ProjectID,
+	)
 
-	client, err := testclient.New(ctx, args.ProjectID, pubsubInfo)
+	client, err := testclient.New(ctx, args.ProjectID, &tfOutputs.PubsubInfo)
 	return client, cleanupTf, err
 }
@@ -25,6 +25,13 @@ import (
 
 const gceTfDir string = "tf/gce"
 
+// The outputs of tf/gce
+type gceOutputs struct {
+	PubsubInfo   setuptf.PubsubInfo `json:"pubsub_info"`
+	InstanceName string             `json:"instance_name"`
+	InstanceZone string             `json:"instance_zone"`
+}
+
 // Set up the instrumented test server to run in GCE container. Creates a new
 // GCE VM + pubsub resources, and runs the specified container image. The
 // returned cleanup function tears down the VM.
@@ -33,7 +40,7 @@ func SetupGce(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (*testclient.Client, e2etesting.Cleanup, error) {
-	pubsubInfo, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
@@ -46,7 +53,18 @@ func SetupGce(
 	if err != nil {
 		return nil, cleanupTf, err
 	}
+	tfOutputs := gceOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return nil, cleanupTf, err
+	}
+	logger.Printf(
+		"Test server running in GCE instance %v: https://console.cloud.google.com/compute/instancesDetail/zones/%v/instances/%v?project=%v\n",
+		tfOutputs.InstanceName,
+		tfOutputs.InstanceZone,
+		tfOutputs.InstanceName,
+		args.ProjectID,
+	)
 
-	client, err := testclient.New(ctx, args.ProjectID, pubsubInfo)
+	client, err := testclient.New(ctx, args.ProjectID, &tfOutputs.PubsubInfo)
 	return client, cleanupTf, err
 }
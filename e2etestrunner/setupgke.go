@@ -25,6 +25,15 @@ import (
 
 const gkeTfDir string = "tf/gke"
 
+// The outputs of tf/gke
+type gkeOutputs struct {
+	PubsubInfo      setuptf.PubsubInfo `json:"pubsub_info"`
+	ClusterName     string             `json:"cluster_name"`
+	ClusterLocation string             `json:"cluster_location"`
+	PodName         string             `json:"pod_name"`
+	Namespace       string             `json:"namespace"`
+}
+
 // Set up the instrumented test server to run in GKE. Creates a new GKE cluster
 // and runs the specified container image in a pod. The returned cleanup
 // function tears down the whole cluster.
@@ -33,7 +42,7 @@ func SetupGke(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (*testclient.Client, e2etesting.Cleanup, error) {
-	pubsubInfo, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
@@ -46,7 +55,21 @@ func SetupGke(
 	if err != nil {
 		return nil, cleanupTf, err
 	}
+	tfOutputs := gkeOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return nil, cleanupTf, err
+	}
+	logger.Printf(
+		"Test server running in GKE pod %v/%v: https://console.cloud.google.com/kubernetes/pod/%v/%v/%v/%v/details?project=%v\n",
+		tfOutputs.Namespace,
+		tfOutputs.PodName,
+		tfOutputs.ClusterLocation,
+		tfOutputs.ClusterName,
+		tfOutputs.Namespace,
+		tfOutputs.PodName,
+		args.ProjectID,
+	)
 
-	client, err := testclient.New(ctx, args.ProjectID, pubsubInfo)
+	client, err := testclient.New(ctx, args.ProjectID, &tfOutputs.PubsubInfo)
 	return client, cleanupTf, err
 }
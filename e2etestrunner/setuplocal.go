@@ -22,6 +22,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/faketrace"
 	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etestrunner/testclient"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
@@ -29,9 +30,26 @@ import (
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
+	"google.golang.org/api/option"
 )
 
-const localTfDir = "tf/local"
+const (
+	localTfDir           = "tf/local"
+	localTransportPubsub = "pubsub"
+	localTransportHTTP   = "http"
+	defaultEmulatorHost  = "host.docker.internal"
+	// Tells the test server to export spans to a plaintext Cloud Trace v2 gRPC
+	// endpoint, without authentication
+	cloudTraceEndpointEnv = "CLOUD_TRACE_ENDPOINT"
+)
+
+// Options for creating the Cloud Trace service in tests, set when the spans
+// should be read from somewhere other than the real API
+var traceServiceOptions []option.ClientOption
+
+// The fake Cloud Trace backend in hermetic runs, which also exposes the v2 spans
+// the test server wrote
+var fakeTraceServer *faketrace.Server
 
 // Set up the instrumented test server for a local run by running in a docker
 // container on the local host
@@ -40,16 +58,53 @@ func SetupLocal(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (*testclient.Client, e2etesting.Cleanup, error) {
-	pubsubInfo, cleanupTf, err := setuptf.SetupTf(
-		ctx,
-		args.ProjectID,
-		args.TestRunID,
-		localTfDir,
-		map[string]string{},
-		logger,
-	)
-	if err != nil {
-		return nil, cleanupTf, err
+	var pubsubInfo *setuptf.PubsubInfo
+	var extraEnv []string
+	cleanupTf := e2etesting.NoopCleanup
+	switch args.Local.Transport {
+	case localTransportPubsub:
+		if args.Local.Hermetic {
+			var emulatorPort int
+			var err error
+			pubsubInfo, emulatorPort, cleanupTf, err = startPubsubEmulator(ctx, args.ProjectID, args.TestRunID, logger)
+			if err != nil {
+				return nil, cleanupTf, err
+			}
+			extraEnv = append(extraEnv, fmt.Sprintf("%v=%v:%v", pubsubEmulatorHostEnv, emulatorHostForContainer(args), emulatorPort))
+			break
+		}
+		var err error
+		pubsubInfo, cleanupTf, err = setuptf.SetupTf(
+			ctx,
+			args.ProjectID,
+			args.TestRunID,
+			localTfDir,
+			map[string]string{},
+			logger,
+		)
+		if err != nil {
+			return nil, cleanupTf, err
+		}
+	case localTransportHTTP:
+		logger.Println("Using HTTP transport, skipping Pub/Sub terraform")
+	default:
+		return nil, cleanupTf, fmt.Errorf("unknown --transport %q, must be %v or %v", args.Local.Transport, localTransportPubsub, localTransportHTTP)
+	}
+
+	if args.Local.Hermetic {
+		fakeTrace, err := faketrace.Start(logger)
+		if err != nil {
+			return nil, cleanupTf, err
+		}
+		logger.Printf("Started fake Cloud Trace receiver on port %v\n", fakeTrace.GRPCPort())
+		cleanupPubsub := cleanupTf
+		cleanupTf = func() {
+			defer cleanupPubsub()
+			fakeTrace.Close()
+		}
+		traceServiceOptions = fakeTrace.ClientOptions()
+		fakeTraceServer = fakeTrace
+		extraEnv = append(extraEnv, fmt.Sprintf("%v=%v:%v", cloudTraceEndpointEnv, emulatorHostForContainer(args), fakeTrace.GRPCPort()))
 	}
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
@@ -58,7 +113,7 @@ func SetupLocal(
 	}
 	cli.NegotiateAPIVersion(ctx)
 
-	createdRes, err := createContainer(ctx, cli, args, pubsubInfo, logger)
+	createdRes, err := createContainer(ctx, cli, args, pubsubInfo, extraEnv, logger)
 	if err != nil {
 		if errdefs.IsNotFound(err) {
 			err = fmt.Errorf(
@@ -102,6 +157,15 @@ func SetupLocal(
 		return nil, cleanup, err
 	}
 
+	if pubsubInfo == nil {
+		baseURL, err := containerBaseURL(ctx, cli, args, containerID)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		logger.Printf("Sending requests directly to test server at %v\n", baseURL)
+		return testclient.NewHTTP(baseURL), cleanup, nil
+	}
+
 	client, err := testclient.New(ctx, args.ProjectID, pubsubInfo)
 	if err != nil {
 		return nil, cleanup, err
@@ -113,15 +177,31 @@ func createContainer(
 	ctx context.Context,
 	cli *client.Client,
 	args *e2etesting.Args,
-	pubsubInfo *setuptf.PubsubInfo,
+	pubsubInfo *setuptf.PubsubInfo, // nil when using the HTTP transport
+	extraEnv []string,
 	logger *log.Logger,
 ) (container.CreateResponse, error) {
-	env := []string{
+	env := append([]string{
 		"PORT=" + args.Local.Port,
 		"PROJECT_ID=" + args.ProjectID,
-		"REQUEST_SUBSCRIPTION_NAME=" + pubsubInfo.RequestTopic.SubscriptionName,
-		"RESPONSE_TOPIC_NAME=" + pubsubInfo.ResponseTopic.TopicName,
-		"SUBSCRIPTION_MODE=" + string(setuptf.Pull),
+	}, extraEnv...)
+	var extraHosts []string
+	if args.Local.Hermetic && args.Local.EmulatorHost == defaultEmulatorHost && args.Local.Network != "host" {
+		// Not defined by default on linux
+		extraHosts = append(extraHosts, defaultEmulatorHost+":host-gateway")
+	}
+	portBindings := nat.PortMap{}
+	if pubsubInfo != nil {
+		env = append(env,
+			"REQUEST_SUBSCRIPTION_NAME="+pubsubInfo.RequestTopic.SubscriptionName,
+			"RESPONSE_TOPIC_NAME="+pubsubInfo.ResponseTopic.TopicName,
+			"SUBSCRIPTION_MODE="+string(setuptf.Pull),
+		)
+	} else {
+		env = append(env, "SUBSCRIPTION_MODE="+string(setuptf.HTTP))
+		// Publish on an ephemeral loopback port so the runner can reach the
+		// server when it isn't on the same docker network
+		portBindings[containerPort(args)] = []nat.PortBinding{{HostIP: "127.0.0.1"}}
 	}
 	mounts := []mount.Mount{}
 	if args.Local.GoogleApplicationCredentials != "" {
@@ -140,13 +220,15 @@ func createContainer(
 			Image: args.Local.Image,
 			Env:   env,
 			ExposedPorts: nat.PortSet{
-				nat.Port(args.Local.Port): struct{}{},
+				containerPort(args): struct{}{},
 			},
 			User: args.Local.ContainerUser,
 		},
 		&container.HostConfig{
-			Mounts:      mounts,
-			NetworkMode: container.NetworkMode(args.Local.Network),
+			Mounts:       mounts,
+			NetworkMode:  container.NetworkMode(args.Local.Network),
+			PortBindings: portBindings,
+			ExtraHosts:   extraHosts,
 		},
 		nil,
 		nil,
@@ -154,6 +236,47 @@ func createContainer(
 	)
 }
 
+// emulatorHostForContainer returns the host name the test server container
+// should use to connect to emulators listening on the runner's host.
+func emulatorHostForContainer(args *e2etesting.Args) string {
+	if args.Local.Network == "host" {
+		return "localhost"
+	}
+	return args.Local.EmulatorHost
+}
+
+func containerPort(args *e2etesting.Args) nat.Port {
+	return nat.Port(args.Local.Port + "/tcp")
+}
+
+// containerBaseURL returns the URL the runner should use to reach the test
+// server's HTTP port. When a docker network is given, the runner is assumed to
+// be attached to it as well and uses the container's IP directly. Otherwise it
+// uses the port published on the host's loopback interface.
+func containerBaseURL(
+	ctx context.Context,
+	cli *client.Client,
+	args *e2etesting.Args,
+	containerID string,
+) (string, error) {
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	if args.Local.Network != "" {
+		if network, ok := inspect.NetworkSettings.Networks[args.Local.Network]; ok && network.IPAddress != "" {
+			return fmt.Sprintf("http://%v:%v", network.IPAddress, args.Local.Port), nil
+		}
+		// e.g. --network=host, where the container shares the runner's ports
+		return fmt.Sprintf("http://localhost:%v", args.Local.Port), nil
+	}
+	bindings := inspect.NetworkSettings.Ports[containerPort(args)]
+	if len(bindings) == 0 {
+		return "", fmt.Errorf("container port %v was not published on the host", containerPort(args))
+	}
+	return fmt.Sprintf("http://%v:%v", bindings[0].HostIP, bindings[0].HostPort), nil
+}
+
 // forward container logs to stdout/stderr
 func startForwardingContainerLogs(
 	ctx context.Context,
@@ -0,0 +1,83 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testclient
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/code"
+)
+
+const (
+	Capabilities string = "/capabilities"
+	// Response attribute with the comma separated list of scenarios the test
+	// server implements
+	Scenarios string = "scenarios"
+)
+
+// ServerCapabilities is what the test server reported in response to the
+// /capabilities scenario.
+type ServerCapabilities struct {
+	// Sorted scenario names, e.g. /basicTrace
+	Scenarios []string
+	// Any other response attributes, e.g. exporter and SDK versions
+	Attributes map[string]string
+}
+
+// Supports returns true if the server claims to implement the scenario.
+func (s *ServerCapabilities) Supports(scenario string) bool {
+	_, found := slices.BinarySearch(s.Scenarios, scenario)
+	return found
+}
+
+// Capabilities asks the test server which scenarios it implements. Returns nil
+// without an error for older test servers which don't implement
+// /capabilities, in which case support can only be discovered per scenario.
+func (c *Client) Capabilities(ctx context.Context) (*ServerCapabilities, error) {
+	res, err := c.Request(ctx, Request{Scenario: Capabilities, TestID: "capabilities"})
+	if err != nil {
+		return nil, err
+	}
+	switch res.StatusCode {
+	case code.Code_OK:
+		return parseCapabilities(res.Headers), nil
+	case code.Code_UNIMPLEMENTED:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf(`got unexpected response code "%v" for %v`, res.StatusCode, Capabilities)
+	}
+}
+
+func parseCapabilities(headers map[string]string) *ServerCapabilities {
+	capabilities := &ServerCapabilities{Attributes: map[string]string{}}
+	for _, scenario := range strings.Split(headers[Scenarios], ",") {
+		if scenario = strings.TrimSpace(scenario); scenario != "" {
+			capabilities.Scenarios = append(capabilities.Scenarios, scenario)
+		}
+	}
+	slices.Sort(capabilities.Scenarios)
+
+	for k, v := range headers {
+		switch k {
+		case TestID, Scenario, StatusCode, Scenarios:
+		default:
+			capabilities.Attributes[k] = v
+		}
+	}
+	return capabilities
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/code"
+)
+
+// HTTPTransport sends requests directly to the test server's HTTP port. It
+// uses the same contract as the Pub/Sub messages: the scenario is the request
+// path, and the test ID and any request headers are sent as HTTP headers. The
+// server replies with the response attributes as HTTP headers, including a
+// status_code header holding the numeric google.rpc.Code.
+type HTTPTransport struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewHTTPTransport(baseURL string) *HTTPTransport {
+	return &HTTPTransport{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{},
+	}
+}
+
+func (h *HTTPTransport) Request(
+	ctx context.Context,
+	request Request,
+) (*Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseURL+request.Scenario, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set(TestID, request.TestID)
+	for k, v := range request.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	httpRes, err := h.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+	// drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, httpRes.Body)
+
+	// HTTP canonicalizes header names, but the Pub/Sub attributes the tests
+	// look up are lower case
+	headers := make(map[string]string, len(httpRes.Header))
+	for k := range httpRes.Header {
+		headers[strings.ToLower(k)] = httpRes.Header.Get(k)
+	}
+
+	statusCode, err := httpStatusToCode(httpRes.StatusCode, headers[StatusCode])
+	if err != nil {
+		return nil, err
+	}
+	return &Response{StatusCode: statusCode, Headers: headers}, nil
+}
+
+// httpStatusToCode prefers the explicit status_code header and falls back to
+// mapping the HTTP status for servers that don't set it.
+func httpStatusToCode(httpStatus int, statusCodeHeader string) (code.Code, error) {
+	if statusCodeHeader != "" {
+		codeInt, err := strconv.Atoi(statusCodeHeader)
+		if err != nil {
+			return code.Code_UNKNOWN, fmt.Errorf(`response invalid header %q: %v`, StatusCode, err)
+		}
+		return code.Code(codeInt), nil
+	}
+	switch {
+	case httpStatus >= 200 && httpStatus < 300:
+		return code.Code_OK, nil
+	case httpStatus == http.StatusNotImplemented || httpStatus == http.StatusNotFound:
+		return code.Code_UNIMPLEMENTED, nil
+	default:
+		return code.Code_UNKNOWN, fmt.Errorf("test server responded with HTTP status %v", httpStatus)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
+	"google.golang.org/genproto/googleapis/rpc/code"
+)
+
+// PubsubTransport publishes requests as attributes on the request topic and
+// matches responses from the response subscription by test ID.
+type PubsubTransport struct {
+	pubsubClient         *pubsub.Client
+	requestTopic         *pubsub.Topic
+	responseSubscription *pubsub.Subscription
+
+	mu              sync.Mutex
+	pendingRequests map[string]chan asyncResponse
+}
+
+type asyncResponse struct {
+	res *Response
+	err error
+}
+
+func NewPubsubTransport(ctx context.Context, projectID string, pubsubInfo *setuptf.PubsubInfo) (*PubsubTransport, error) {
+	pubsub, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	transport := &PubsubTransport{
+		pubsubClient:         pubsub,
+		requestTopic:         pubsub.Topic(pubsubInfo.RequestTopic.TopicName),
+		responseSubscription: pubsub.Subscription(pubsubInfo.ResponseTopic.SubscriptionName),
+		pendingRequests:      make(map[string]chan asyncResponse),
+	}
+	// Disable buffering
+	transport.requestTopic.PublishSettings.CountThreshold = 1
+
+	go transport.startReceiver(ctx)
+
+	return transport, nil
+}
+
+func (p *PubsubTransport) startReceiver(ctx context.Context) {
+	err := p.responseSubscription.Receive(ctx, func(ctx context.Context, message *pubsub.Message) {
+		testID := message.Attributes[TestID]
+
+		p.mu.Lock()
+		ch, ok := p.pendingRequests[testID]
+		p.mu.Unlock()
+
+		if ok {
+			message.Ack()
+			var resErr error
+			var res *Response
+			codeInt, err := strconv.Atoi(message.Attributes[StatusCode])
+			if err != nil {
+				resErr = fmt.Errorf(`response pub/sub message invalid attribute %q: %v, message: %v`, StatusCode, err, message)
+			} else {
+				res = &Response{StatusCode: code.Code(codeInt), Headers: message.Attributes}
+			}
+			ch <- asyncResponse{res: res, err: resErr}
+		} else {
+			message.Nack()
+		}
+	})
+	if err != nil {
+		log.Printf("Background subscriber error: %v", err)
+	}
+}
+
+func (p *PubsubTransport) Request(
+	ctx context.Context,
+	request Request,
+) (*Response, error) {
+	attributes := map[string]string{TestID: request.TestID, Scenario: request.Scenario}
+	for k, v := range request.Headers {
+		attributes[k] = v
+	}
+
+	resCh := make(chan asyncResponse, 1)
+	p.mu.Lock()
+	p.pendingRequests[request.TestID] = resCh
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.pendingRequests, request.TestID)
+		p.mu.Unlock()
+	}()
+
+	pubResult := p.requestTopic.Publish(ctx, &pubsub.Message{
+		Attributes: attributes,
+	})
+	messageID, err := pubResult.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf(
+			"sent message ID %v, but never received a response on subscription %v: %w",
+			messageID,
+			p.responseSubscription.String(),
+			ctx.Err(),
+		)
+	case asyncRes := <-resCh:
+		return asyncRes.res, asyncRes.err
+	}
+}
@@ -16,13 +16,11 @@ package testclient
 
 import (
 	"context"
-	"fmt"
-	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
 	"log"
-	"strconv"
-	"sync"
+	"time"
 
-	"cloud.google.com/go/pubsub"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
+	"github.com/sethvargo/go-retry"
 	"google.golang.org/genproto/googleapis/rpc/code"
 )
 
@@ -45,111 +43,56 @@ type Response struct {
 	Headers    map[string]string
 }
 
-type Client struct {
-	pubsubClient         *pubsub.Client
-	requestTopic         *pubsub.Topic
-	responseSubscription *pubsub.Subscription
-
-	mu              sync.Mutex
-	pendingRequests map[string]chan asyncResponse
+// Transport delivers a single scenario request to the instrumented test server
+// and waits for its response. Implementations must be safe for concurrent use.
+type Transport interface {
+	Request(ctx context.Context, request Request) (*Response, error)
 }
 
-type asyncResponse struct {
-	res *Response
-	err error
+type Client struct {
+	transport Transport
 }
 
+// New creates a Client which talks to the test server over the request and
+// response Pub/Sub topics created by setuptf.
 func New(ctx context.Context, projectID string, pubsubInfo *setuptf.PubsubInfo) (*Client, error) {
-	pubsub, err := pubsub.NewClient(ctx, projectID)
+	transport, err := NewPubsubTransport(ctx, projectID, pubsubInfo)
 	if err != nil {
 		return nil, err
 	}
-	client := &Client{
-		pubsubClient:         pubsub,
-		requestTopic:         pubsub.Topic(pubsubInfo.RequestTopic.TopicName),
-		responseSubscription: pubsub.Subscription(pubsubInfo.ResponseTopic.SubscriptionName),
-		pendingRequests:      make(map[string]chan asyncResponse),
-	}
-	// Disable buffering
-	client.requestTopic.PublishSettings.CountThreshold = 1
-
-	go client.startReceiver(ctx)
-
-	return client, nil
+	return NewWithTransport(transport), nil
 }
 
-func (c *Client) startReceiver(ctx context.Context) {
-	err := c.responseSubscription.Receive(ctx, func(ctx context.Context, message *pubsub.Message) {
-		testID := message.Attributes[TestID]
-
-		c.mu.Lock()
-		ch, ok := c.pendingRequests[testID]
-		c.mu.Unlock()
+// NewHTTP creates a Client which sends requests straight to the test server's
+// HTTP port at baseURL, e.g. http://localhost:8000.
+func NewHTTP(baseURL string) *Client {
+	return NewWithTransport(NewHTTPTransport(baseURL))
+}
 
-		if ok {
-			message.Ack()
-			var resErr error
-			var res *Response
-			codeInt, err := strconv.Atoi(message.Attributes[StatusCode])
-			if err != nil {
-				resErr = fmt.Errorf(`response pub/sub message invalid attribute %q: %v, message: %v`, StatusCode, err, message)
-			} else {
-				res = &Response{StatusCode: code.Code(codeInt), Headers: message.Attributes}
-			}
-			ch <- asyncResponse{res: res, err: resErr}
-		} else {
-			message.Nack()
-		}
-	})
-	if err != nil {
-		log.Printf("Background subscriber error: %v", err)
-	}
+func NewWithTransport(transport Transport) *Client {
+	return &Client{transport: transport}
 }
 
 func (c *Client) Request(
 	ctx context.Context,
 	request Request,
 ) (*Response, error) {
-	attributes := map[string]string{TestID: request.TestID, "scenario": request.Scenario}
-	for k, v := range request.Headers {
-		attributes[k] = v
-	}
-
-	resCh := make(chan asyncResponse, 1)
-	c.mu.Lock()
-	c.pendingRequests[request.TestID] = resCh
-	c.mu.Unlock()
-
-	defer func() {
-		c.mu.Lock()
-		delete(c.pendingRequests, request.TestID)
-		c.mu.Unlock()
-	}()
-
-	pubResult := c.requestTopic.Publish(ctx, &pubsub.Message{
-		Attributes: attributes,
-	})
-	messageID, err := pubResult.Get(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	select {
-	case <-ctx.Done():
-		return nil, fmt.Errorf(
-			"sent message ID %v, but never received a response on subscription %v: %w",
-			messageID,
-			c.responseSubscription.String(),
-			ctx.Err(),
-		)
-	case asyncRes := <-resCh:
-		return asyncRes.res, asyncRes.err
-	}
+	return c.transport.Request(ctx, request)
 }
 
 // Call in TestMain() to block until the test server is ready for requests. Uses
 // a *log.Logger because this runs before testing.T is available
 func (c *Client) WaitForHealth(ctx context.Context, logger *log.Logger) error {
-	_, err := c.Request(ctx, Request{Scenario: Health})
-	return err
+	backoff, err := retry.NewConstant(time.Second)
+	if err != nil {
+		return err
+	}
+	return retry.Do(ctx, backoff, func(ctx context.Context) error {
+		_, err := c.Request(ctx, Request{Scenario: Health})
+		if err != nil && ctx.Err() == nil {
+			logger.Printf("Test server not healthy yet, retrying: %v\n", err)
+			return retry.RetryableError(err)
+		}
+		return err
+	})
 }
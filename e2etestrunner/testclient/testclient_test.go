@@ -2,10 +2,16 @@ package testclient
 
 import (
 	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/pubsub"
 	"cloud.google.com/go/pubsub/pstest"
@@ -126,3 +132,93 @@ func TestClientRequest(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestHTTPClientRequest(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/my-scenario", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(TestID, r.Header.Get(TestID))
+		w.Header().Set(StatusCode, strconv.Itoa(int(code.Code_OK)))
+		w.Header().Set("custom", r.Header.Get("foo"))
+	})
+	mux.HandleFunc("/unsupported", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotImplemented)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	sut := NewHTTP(srv.URL)
+
+	t.Run("single request", func(t *testing.T) {
+		resp, err := sut.Request(ctx, Request{
+			TestID:   "test-123",
+			Scenario: "/my-scenario",
+			Headers:  map[string]string{"foo": "bar"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, code.Code_OK, resp.StatusCode)
+		assert.Equal(t, "bar", resp.Headers["custom"])
+		assert.Equal(t, "test-123", resp.Headers[TestID])
+	})
+
+	t.Run("unimplemented without status_code header", func(t *testing.T) {
+		resp, err := sut.Request(ctx, Request{TestID: "test-456", Scenario: "/unsupported"})
+		require.NoError(t, err)
+		assert.Equal(t, code.Code_UNIMPLEMENTED, resp.StatusCode)
+	})
+}
+
+func TestWaitForHealthRetries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Simulate a server that isn't ready for the first request
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, Health, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	err := NewHTTP(srv.URL).WaitForHealth(ctx, log.New(io.Discard, "", 0))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestCapabilities(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("supported", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, Capabilities, r.URL.Path)
+			w.Header().Set(StatusCode, strconv.Itoa(int(code.Code_OK)))
+			w.Header().Set(Scenarios, "/complexTrace, /basicTrace")
+			w.Header().Set("exporter_version", "1.2.3")
+		}))
+		defer srv.Close()
+
+		capabilities, err := NewHTTP(srv.URL).Capabilities(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, capabilities)
+		assert.Equal(t, []string{"/basicTrace", "/complexTrace"}, capabilities.Scenarios)
+		assert.True(t, capabilities.Supports("/basicTrace"))
+		assert.False(t, capabilities.Supports("/basicPropagator"))
+		assert.Equal(t, "1.2.3", capabilities.Attributes["exporter_version"])
+		assert.NotContains(t, capabilities.Attributes, StatusCode)
+	})
+
+	t.Run("older test server", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(StatusCode, strconv.Itoa(int(code.Code_UNIMPLEMENTED)))
+		}))
+		defer srv.Close()
+
+		capabilities, err := NewHTTP(srv.URL).Capabilities(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, capabilities)
+	})
+}
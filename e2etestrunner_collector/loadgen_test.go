@@ -0,0 +1,165 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2ecollector
+
+package e2etestrunner_collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"cloud.google.com/go/logging/logadmin"
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	monitoringpb "cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/api/cloudtrace/v1"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/loadgen"
+)
+
+// The load generator's telemetry is all sent by the time the tests run, since
+// applying terraform waits for its job to complete. These tests check all of
+// it was stored, exactly once.
+
+func skipWithoutLoadgen(t *testing.T) {
+	if loadgenArgs == nil {
+		t.Skip("No load generator was deployed, pass --loadgen-image to gke-collector to run it")
+	}
+}
+
+func TestLoadgenTraces(t *testing.T) {
+	skipWithoutLoadgen(t)
+	ctx := context.Background()
+	cloudService, err := cloudtrace.NewService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := loadgenArgs.LoadgenSpans
+	filter := fmt.Sprintf("%s:%s", loadgen.TestRunIDKey, args.TestRunID)
+	spans, err := retryQueryAtLeast(ctx, "ListTraces", want, args.TraceBackoffInitial, args.TraceBackoffTotal, t.Logf,
+		func(ctx context.Context) ([]*cloudtrace.TraceSpan, error) {
+			traces, err := listTraces(ctx, cloudService, args.ProjectID, filter, "COMPLETE", runStart, want+1)
+			if err != nil {
+				return nil, err
+			}
+			// The filter matches label values by prefix
+			var spans []*cloudtrace.TraceSpan
+			for _, trace := range traces {
+				for _, span := range trace.Spans {
+					if span.Name == loadgen.SpanName && span.Labels[loadgen.TestRunIDKey] == args.TestRunID {
+						spans = append(spans, span)
+					}
+				}
+			}
+			return spans, nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("Found %d of the %d spans sent by the load generator: %v", len(spans), want, err)
+	}
+	ids := map[string]int{}
+	for _, span := range spans {
+		ids[fmt.Sprint(span.SpanId)]++
+	}
+	checkLoadgenCount(t, "spans", ids, want)
+}
+
+func TestLoadgenMetrics(t *testing.T) {
+	skipWithoutLoadgen(t)
+	ctx := context.Background()
+	c, err := monitoring.NewMetricClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	want := loadgenArgs.LoadgenMetricSeries
+	filters := []string{
+		fmt.Sprintf("metric.type = %q", variant.LoadgenMetric),
+		fmt.Sprintf("metric.labels.%s = %q", loadgen.TestRunIDKey, args.TestRunID),
+	}
+	// The load generator's resource only has service.name, so its metrics
+	// aren't written to the platform's resource type
+	if variant.MetricResourceType != "" {
+		filters = append(filters, fmt.Sprintf("resource.type = %q", variant.MetricResourceType))
+	}
+	filter := strings.Join(filters, " AND ")
+	tsList, err := retryQueryAtLeast(ctx, "ListTimeSeries", want, args.MetricBackoffInitial, args.MetricBackoffTotal, t.Logf,
+		func(ctx context.Context) ([]*monitoringpb.TimeSeries, error) {
+			return listTimeSeries(ctx, c, args.ProjectID, filter, monitoringpb.ListTimeSeriesRequest_FULL, runStart, want+1)
+		},
+	)
+	if err != nil {
+		t.Fatalf("Found %d of the %d metric series sent by the load generator: %v", len(tsList), want, err)
+	}
+	series := map[string]int{}
+	for _, ts := range tsList {
+		series[ts.Metric.Labels[loadgen.SeriesKey]]++
+		for _, point := range ts.Points {
+			value := point.GetValue().GetInt64Value()
+			if value == 0 {
+				value = int64(point.GetValue().GetDoubleValue())
+			}
+			if value != 1 {
+				t.Errorf("Metric series %s=%s has a point of %v, expected 1", loadgen.SeriesKey, ts.Metric.Labels[loadgen.SeriesKey], point.GetValue())
+			}
+		}
+	}
+	checkLoadgenCount(t, "metric series", series, want)
+}
+
+func TestLoadgenLogs(t *testing.T) {
+	skipWithoutLoadgen(t)
+	ctx := context.Background()
+	client, err := logadmin.NewClient(ctx, args.ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	want := loadgenArgs.LoadgenLogs
+	filter := fmt.Sprintf(`labels.%s="%s"`, loadgen.TestRunIDKey, args.TestRunID)
+	entries, err := retryQueryAtLeast(ctx, "ListLogEntries", want, args.LogBackoffInitial, args.LogBackoffTotal, t.Logf,
+		func(ctx context.Context) ([]*logging.Entry, error) {
+			return listLogEntries(ctx, client, filter, runStart, want+1)
+		},
+	)
+	if err != nil {
+		t.Fatalf("Found %d of the %d log records sent by the load generator: %v", len(entries), want, err)
+	}
+	indexes := map[string]int{}
+	for _, entry := range entries {
+		indexes[entry.Labels[loadgen.LogIndexKey]]++
+	}
+	checkLoadgenCount(t, "log records", indexes, want)
+}
+
+// Checks each of the want sent items was stored exactly once, given how many
+// times each was found by its ID
+func checkLoadgenCount(t *testing.T, what string, found map[string]int, want int) {
+	total := 0
+	for id, n := range found {
+		total += n
+		if n > 1 {
+			t.Errorf("Found %d copies of the load generator's %s with ID %q", n, what, id)
+		}
+	}
+	if len(found) != want {
+		t.Errorf("Found %d distinct %s of the %d sent by the load generator (%d in total)", len(found), what, want, total)
+	}
+}
@@ -3,6 +3,7 @@
 package e2etestrunner_collector
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -11,12 +12,15 @@ import (
 	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
 )
 
-const labelName = "otelcol_google_e2e"
-
 var (
-	args           e2etesting.Args
-	resourceType   string
-	resourceFilter string
+	args         e2etesting.Args
+	resourceType string
+	// What to expect of the telemetry with the --config-variant
+	variant configVariant
+	// When the collector was set up, telemetry is queried from then on
+	runStart time.Time
+	// How much telemetry the load generator sent, nil if it wasn't deployed
+	loadgenArgs *e2etesting.CmdWithLoadgen
 )
 
 func TestMain(m *testing.M) {
@@ -24,7 +28,6 @@ func TestMain(m *testing.M) {
 	if shouldExit {
 		return
 	}
-	resourceFilter = fmt.Sprintf("otelcol_google_e2e:%s", args.TestRunID)
 	var setupFunc e2etesting.SetupCollectorFunc
 	switch {
 	case args.GceCollector != nil:
@@ -36,6 +39,9 @@ func TestMain(m *testing.M) {
 	case args.GkeCollector != nil:
 		setupFunc = SetupGkeCollector
 		resourceType = "k8s_container"
+		if args.GkeCollector.LoadgenImage != "" {
+			loadgenArgs = &args.GkeCollector.CmdWithLoadgen
+		}
 	case args.GkeOperatorCollector != nil:
 		setupFunc = SetupGkeOperatorCollector
 		resourceType = "k8s_container"
@@ -43,18 +49,27 @@ func TestMain(m *testing.M) {
 		setupFunc = SetupCloudRunCollector
 		resourceType = "generic_task"
 	}
-	cleanup, err := setupFunc(ctx, &args, logger)
+	var err error
+	variant, err = lookupConfigVariant(args.CollectorConfigVariant())
+	if err != nil {
+		logger.Panic(err)
+	}
+	recorder := args.NewReportRecorder()
+	teardown := e2etesting.NewTeardown(ctx, logger)
+	defer teardown.RunOnExit()
+	ctx = teardown.Context()
 
-	defer cleanup()
+	runStart = time.Now()
+	err = teardown.Setup(fmt.Sprintf("test run %v", args.TestRunID), func(ctx context.Context) (e2etesting.Cleanup, error) {
+		return setupFunc(ctx, &args, logger)
+	})
 	if err != nil {
 		logger.Panic(err)
 	}
 
-	// wait for instrumented test server to be healthy
-	logger.Printf("Waiting for health check on (will timeout after %v)\n", args.HealthCheckTimeout)
-	time.Sleep(args.HealthCheckTimeout)
+	if err := WaitForCollector(ctx, &args, variant, resourceType, runStart, logger); err != nil {
+		logger.Panic(err)
+	}
 	// Run tests
-	logger.Print(e2etesting.BeginOutputArt)
-	m.Run()
-	logger.Print(e2etesting.EndOutputArt)
+	e2etesting.RunTests(m, &args, recorder, logger)
 }
@@ -0,0 +1,213 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2etestrunner_collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"cloud.google.com/go/logging/logadmin"
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	monitoringpb "cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/sethvargo/go-retry"
+	"google.golang.org/api/cloudtrace/v1"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// Allow for clock skew between the runner and the collector
+	queryClockSkew = time.Minute
+	// Longest wait between queries
+	queryMaxBackoff = 30 * time.Second
+	// Stop paging after this many results, plenty to check the telemetry
+	maxQueryResults = 1000
+	tracePageSize   = 100
+)
+
+var (
+	errNoResults     = errors.New("no results yet")
+	errTooFewResults = errors.New("not all results yet")
+)
+
+// retryQuery runs query with exponential backoff until it returns results, an
+// error which won't go away by waiting, or total has elapsed. The query's
+// context has a deadline of when total elapses. Telemetry takes a while to
+// become queryable after it's written, so all of the signals are queried this
+// way.
+func retryQuery[T any](
+	ctx context.Context,
+	what string,
+	initialBackoff time.Duration,
+	total time.Duration,
+	logf func(format string, args ...any),
+	query func(ctx context.Context) ([]T, error),
+) ([]T, error) {
+	results, err := retryQueryAtLeast(ctx, what, 1, initialBackoff, total, logf, query)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// retryQueryAtLeast is like retryQuery, but keeps querying until there are at
+// least want results, e.g. until all of the load generator's spans are stored.
+// On error, it also returns the results of the last successful query.
+func retryQueryAtLeast[T any](
+	ctx context.Context,
+	what string,
+	want int,
+	initialBackoff time.Duration,
+	total time.Duration,
+	logf func(format string, args ...any),
+	query func(ctx context.Context) ([]T, error),
+) ([]T, error) {
+	backoff, err := retry.NewExponential(initialBackoff)
+	if err != nil {
+		return nil, err
+	}
+	backoff = retry.WithMaxDuration(total, retry.WithCappedDuration(queryMaxBackoff, backoff))
+	ctx, cancel := context.WithTimeout(ctx, total)
+	defer cancel()
+
+	start := time.Now()
+	var results []T
+	var lastErr error
+	err = retry.Do(ctx, backoff, func(ctx context.Context) error {
+		var res []T
+		res, lastErr = query(ctx)
+		if lastErr == nil {
+			results = res
+		}
+		switch {
+		case lastErr == nil && len(res) >= want:
+			return nil
+		case isPermanent(lastErr):
+			return lastErr
+		case lastErr == nil && len(res) == 0:
+			lastErr = errNoResults
+		case lastErr == nil:
+			lastErr = fmt.Errorf("%w, %v of %v so far", errTooFewResults, len(res), want)
+		}
+		logf("Retrying %v after %v: %v", what, time.Since(start).Round(time.Second), lastErr)
+		return retry.RetryableError(lastErr)
+	})
+	if err == nil {
+		return results, nil
+	}
+	if lastErr == nil || errors.Is(err, lastErr) {
+		return results, fmt.Errorf("%v: %w", what, err)
+	}
+	return results, fmt.Errorf("%v: %w after %v: %w", what, err, time.Since(start).Round(time.Second), lastErr)
+}
+
+// listTimeSeries returns up to limit time series matching the filter which have
+// points since the given time. With the HEADERS view, the points are left out.
+func listTimeSeries(
+	ctx context.Context,
+	client *monitoring.MetricClient,
+	projectID string,
+	filter string,
+	view monitoringpb.ListTimeSeriesRequest_TimeSeriesView,
+	since time.Time,
+	limit int,
+) ([]*monitoringpb.TimeSeries, error) {
+	it := client.ListTimeSeries(ctx, &monitoringpb.ListTimeSeriesRequest{
+		Name:   "projects/" + projectID,
+		Filter: filter,
+		Interval: &monitoringpb.TimeInterval{
+			StartTime: timestamppb.New(since.Add(-queryClockSkew)),
+			EndTime:   timestamppb.Now(),
+		},
+		View: view,
+	})
+	var tsList []*monitoringpb.TimeSeries
+	for len(tsList) < limit {
+		series, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if view == monitoringpb.ListTimeSeriesRequest_FULL && len(series.Points) == 0 {
+			continue
+		}
+		tsList = append(tsList, series)
+	}
+	return tsList, nil
+}
+
+// listLogEntries returns up to limit log entries matching the filter written
+// since the given time, oldest first.
+func listLogEntries(
+	ctx context.Context,
+	client *logadmin.Client,
+	filter string,
+	since time.Time,
+	limit int,
+) ([]*logging.Entry, error) {
+	filter = fmt.Sprintf(`%s AND timestamp >= "%s"`, filter, since.Add(-queryClockSkew).Format(time.RFC3339))
+	it := client.Entries(ctx, logadmin.Filter(filter))
+	var entries []*logging.Entry
+	for len(entries) < limit {
+		entry, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// listTraces returns up to limit traces matching the filter which ended since
+// the given time. view is MINIMAL, ROOTSPAN or COMPLETE.
+func listTraces(
+	ctx context.Context,
+	service *cloudtrace.Service,
+	projectID string,
+	filter string,
+	view string,
+	since time.Time,
+	limit int,
+) ([]*cloudtrace.Trace, error) {
+	var traces []*cloudtrace.Trace
+	errDone := errors.New("done")
+	err := service.Projects.Traces.List(projectID).
+		Filter(filter).
+		View(view).
+		StartTime(since.Add(-queryClockSkew).Format(time.RFC3339)).
+		EndTime(time.Now().Format(time.RFC3339)).
+		PageSize(int64(min(limit, tracePageSize))).
+		Pages(ctx, func(res *cloudtrace.ListTracesResponse) error {
+			for _, trace := range res.Traces {
+				traces = append(traces, trace)
+				if len(traces) == limit {
+					return errDone
+				}
+			}
+			return nil
+		})
+	if err != nil && !errors.Is(err, errDone) {
+		return nil, err
+	}
+	return traces, nil
+}
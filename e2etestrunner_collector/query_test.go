@@ -0,0 +1,108 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Offline unit tests, excluded from the e2ecollector test binary
+//go:build !e2ecollector
+
+package e2etestrunner_collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryQuery(t *testing.T) {
+	queries := 0
+	query := func(ctx context.Context) ([]string, error) {
+		queries++
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		switch queries {
+		case 1:
+			return nil, nil
+		case 2:
+			return nil, status.Error(codes.Unavailable, "try again")
+		}
+		return []string{"found"}, nil
+	}
+
+	results, err := retryQuery(context.Background(), "ListThings", time.Millisecond, time.Minute, t.Logf, query)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"found"}, results)
+	assert.Equal(t, 3, queries)
+}
+
+func TestRetryQueryTimeout(t *testing.T) {
+	query := func(ctx context.Context) ([]string, error) {
+		return nil, nil
+	}
+
+	start := time.Now()
+	_, err := retryQuery(context.Background(), "ListThings", time.Millisecond, 50*time.Millisecond, t.Logf, query)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.ErrorIs(t, err, errNoResults)
+	assert.ErrorContains(t, err, "ListThings")
+}
+
+func TestRetryQueryPermanentError(t *testing.T) {
+	queries := 0
+	query := func(ctx context.Context) ([]string, error) {
+		queries++
+		return nil, status.Error(codes.InvalidArgument, "invalid filter")
+	}
+
+	_, err := retryQuery(context.Background(), "ListThings", time.Millisecond, time.Minute, t.Logf, query)
+	assert.Equal(t, 1, queries)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRetryQueryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	query := func(ctx context.Context) ([]string, error) {
+		cancel()
+		return nil, nil
+	}
+
+	_, err := retryQuery(ctx, "ListThings", time.Second, time.Minute, t.Logf, query)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRetryQueryAtLeast(t *testing.T) {
+	queries := 0
+	query := func(ctx context.Context) ([]int, error) {
+		queries++
+		return make([]int, queries), nil
+	}
+
+	results, err := retryQueryAtLeast(context.Background(), "ListThings", 3, time.Millisecond, time.Minute, t.Logf, query)
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+}
+
+func TestRetryQueryAtLeastTimeout(t *testing.T) {
+	query := func(ctx context.Context) ([]int, error) {
+		return []int{1, 2}, nil
+	}
+
+	results, err := retryQueryAtLeast(context.Background(), "ListThings", 3, time.Millisecond, 50*time.Millisecond, t.Logf, query)
+	assert.ErrorIs(t, err, errTooFewResults)
+	assert.ErrorContains(t, err, "2 of 3 so far")
+	assert.Len(t, results, 2)
+}
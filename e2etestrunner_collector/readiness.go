@@ -0,0 +1,225 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2etestrunner_collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/logging/logadmin"
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	monitoringpb "cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/sethvargo/go-retry"
+	"google.golang.org/api/cloudtrace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting"
+)
+
+const (
+	// The collector exports its own metrics through the otlp/internal receiver
+	// every 10s, so this shows up soon after it starts
+	representativeMetric = "workload.googleapis.com/otelcol_exporter_sent_metric_points"
+	labelName            = "otelcol_google_e2e"
+	// Added to every span by the googlecloud exporter
+	agentLabel = "g.co/agent"
+
+	// Longest wait between readiness checks
+	readinessMaxBackoff = 30 * time.Second
+)
+
+var errNotReady = errors.New("no telemetry from the collector yet")
+
+// readinessProbe returns nil once the collector is ready, errNotReady while it
+// isn't yet, or another error if checking failed.
+type readinessProbe func(ctx context.Context) error
+
+// WaitForCollector waits until the collector's own metrics reach Cloud
+// Monitoring, which means it started and its metrics exporter works.
+// Checks with exponential backoff starting at --metric-backoff-initial, giving
+// up after --health-check-timeout with a diagnostic of which telemetry did
+// arrive.
+func WaitForCollector(
+	ctx context.Context,
+	args *e2etesting.Args,
+	variant configVariant,
+	resourceType string,
+	since time.Time,
+	logger *log.Logger,
+) error {
+	metricClient, err := monitoring.NewMetricClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer metricClient.Close()
+	probe := func(ctx context.Context) error {
+		filter := metricFilter(variant.RepresentativeMetric, variant.metricResourceType(resourceType), args.TestRunID)
+		found, err := hasMetricPoints(ctx, metricClient, args.ProjectID, filter, since)
+		if err != nil || !found {
+			return errors.Join(errNotReady, err)
+		}
+		return nil
+	}
+	diagnose := func(ctx context.Context) string {
+		return diagnoseCollector(ctx, args.ProjectID, resourceType, args.TestRunID, since)
+	}
+	return waitForReady(ctx, probe, diagnose, args.MetricBackoffInitial, args.HealthCheckTimeout, logger)
+}
+
+func waitForReady(
+	ctx context.Context,
+	probe readinessProbe,
+	diagnose func(context.Context) string,
+	initialBackoff time.Duration,
+	timeout time.Duration,
+	logger *log.Logger,
+) error {
+	logger.Printf("Waiting for the collector to be ready (will timeout after %v)\n", timeout)
+	backoff, err := retry.NewExponential(initialBackoff)
+	if err != nil {
+		return err
+	}
+	backoff = retry.WithMaxDuration(timeout, retry.WithCappedDuration(readinessMaxBackoff, backoff))
+
+	start := time.Now()
+	var lastErr error
+	err = retry.Do(ctx, backoff, func(ctx context.Context) error {
+		lastErr = probe(ctx)
+		switch {
+		case lastErr == nil:
+			return nil
+		case isPermanent(lastErr):
+			return lastErr
+		default:
+			logger.Printf("Collector not ready after %v: %v\n", time.Since(start).Round(time.Second), lastErr)
+			return retry.RetryableError(lastErr)
+		}
+	})
+	if err == nil {
+		logger.Printf("Collector ready after %v\n", time.Since(start).Round(time.Second))
+		return nil
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+	if isPermanent(lastErr) {
+		return fmt.Errorf("checking whether the collector is ready: %w", lastErr)
+	}
+	return fmt.Errorf(
+		"collector was not ready after %v: %w\n%v",
+		time.Since(start).Round(time.Second),
+		lastErr,
+		diagnose(context.WithoutCancel(ctx)),
+	)
+}
+
+// Errors which won't go away by waiting, e.g. missing permissions
+func isPermanent(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.PermissionDenied, codes.Unauthenticated, codes.NotFound:
+		return true
+	}
+	return false
+}
+
+func hasMetricPoints(
+	ctx context.Context,
+	client *monitoring.MetricClient,
+	projectID string,
+	filter string,
+	since time.Time,
+) (bool, error) {
+	tsList, err := listTimeSeries(ctx, client, projectID, filter, monitoringpb.ListTimeSeriesRequest_HEADERS, since, 1)
+	return len(tsList) > 0, err
+}
+
+// Filters the collector's representative metric from this test run
+func metricFilter(metricType, resourceType, testRunID string) string {
+	filters := []string{
+		fmt.Sprintf("metric.type = %q", metricType),
+		fmt.Sprintf("resource.type = %q", resourceType),
+		fmt.Sprintf("metric.labels.%s = %s", labelName, testRunID),
+	}
+	return strings.Join(filters, " AND ")
+}
+
+// Filters the collector's logs from this test run
+func logFilter(resourceType, testRunID string) string {
+	return fmt.Sprintf(`resource.type="%s" AND labels.%s="%s"`, resourceType, labelName, testRunID)
+}
+
+// Filters the traces from this test run. Matches label values by prefix.
+func traceFilter(testRunID string) string {
+	return fmt.Sprintf("%s:%s", labelName, testRunID)
+}
+
+// Describes which of the collector's telemetry reached the backends, to tell a
+// collector which never started from one with a broken pipeline.
+func diagnoseCollector(ctx context.Context, projectID, resourceType, testRunID string, since time.Time) string {
+	var found, failed []string
+	check := func(signal string, query func() (bool, error)) {
+		ok, err := query()
+		switch {
+		case err != nil:
+			failed = append(failed, fmt.Sprintf("%v (%v)", signal, err))
+		case ok:
+			found = append(found, signal)
+		}
+	}
+
+	check("logs", func() (bool, error) {
+		client, err := logadmin.NewClient(ctx, projectID)
+		if err != nil {
+			return false, err
+		}
+		defer client.Close()
+		entries, err := listLogEntries(ctx, client, logFilter(resourceType, testRunID), since, 1)
+		return len(entries) > 0, err
+	})
+	check("traces", func() (bool, error) {
+		service, err := cloudtrace.NewService(ctx)
+		if err != nil {
+			return false, err
+		}
+		traces, err := listTraces(ctx, service, projectID, traceFilter(testRunID), "MINIMAL", since, 1)
+		return len(traces) > 0, err
+	})
+
+	var diagnostic string
+	if len(found) == 0 {
+		diagnostic = fmt.Sprintf(
+			"No logs or traces with %v=%v arrived either, so the collector most likely failed to start or can't export at all. Check its logs on the %v resource.",
+			labelName,
+			testRunID,
+			resourceType,
+		)
+	} else {
+		diagnostic = fmt.Sprintf(
+			"Its %v with %v=%v arrived, so the collector is running but its metrics aren't exported. Check its metrics pipeline and exporter.",
+			strings.Join(found, " and "),
+			labelName,
+			testRunID,
+		)
+	}
+	if len(failed) > 0 {
+		diagnostic += fmt.Sprintf(" Couldn't check for %v.", strings.Join(failed, ", "))
+	}
+	return diagnostic
+}
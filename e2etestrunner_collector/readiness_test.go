@@ -0,0 +1,98 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Offline unit tests, excluded from the e2ecollector test binary
+//go:build !e2ecollector
+
+package e2etestrunner_collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func noDiagnosis(t *testing.T) func(context.Context) string {
+	return func(context.Context) string {
+		t.Error("should not diagnose")
+		return ""
+	}
+}
+
+func TestWaitForReady(t *testing.T) {
+	var logs bytes.Buffer
+	probes := 0
+	probe := func(ctx context.Context) error {
+		probes++
+		if probes < 3 {
+			return errNotReady
+		}
+		return nil
+	}
+
+	err := waitForReady(context.Background(), probe, noDiagnosis(t), time.Millisecond, time.Minute, log.New(&logs, "", 0))
+	require.NoError(t, err)
+	assert.Equal(t, 3, probes)
+	assert.Contains(t, logs.String(), "Collector not ready after")
+	assert.Contains(t, logs.String(), "Collector ready after")
+}
+
+func TestWaitForReadyTimeout(t *testing.T) {
+	probe := func(ctx context.Context) error {
+		return errors.Join(errNotReady, status.Error(codes.Unavailable, "try again"))
+	}
+	diagnose := func(ctx context.Context) string {
+		assert.NoError(t, ctx.Err())
+		return "No logs or traces arrived either"
+	}
+
+	start := time.Now()
+	err := waitForReady(context.Background(), probe, diagnose, time.Millisecond, 50*time.Millisecond, log.New(&bytes.Buffer{}, "", 0))
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.ErrorIs(t, err, errNotReady)
+	assert.ErrorContains(t, err, "collector was not ready after")
+	assert.ErrorContains(t, err, "try again")
+	assert.ErrorContains(t, err, "No logs or traces arrived either")
+}
+
+func TestWaitForReadyPermanentError(t *testing.T) {
+	probes := 0
+	probe := func(ctx context.Context) error {
+		probes++
+		return errors.Join(errNotReady, status.Error(codes.PermissionDenied, "monitoring.timeSeries.list denied"))
+	}
+
+	err := waitForReady(context.Background(), probe, noDiagnosis(t), time.Millisecond, time.Minute, log.New(&bytes.Buffer{}, "", 0))
+	assert.Equal(t, 1, probes)
+	assert.ErrorContains(t, err, "monitoring.timeSeries.list denied")
+}
+
+func TestWaitForReadyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	probe := func(ctx context.Context) error {
+		cancel()
+		return errNotReady
+	}
+
+	err := waitForReady(ctx, probe, noDiagnosis(t), time.Second, time.Minute, log.New(&bytes.Buffer{}, "", 0))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2etestrunner_collector
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The default_log_name of the googlecloud exporter in tf/modules/otel-config
+const defaultLogName = "google-otelcol/smoke-test"
+
+// The monitored resource labels the exporters fill in from the attributes the
+// resourcedetection processor detected
+var requiredResourceLabels = map[string][]string{
+	"gce_instance":  {"instance_id", "zone"},
+	"k8s_container": {"location", "cluster_name", "namespace_name", "pod_name", "container_name"},
+	"generic_task":  {"location", "job", "task_id"},
+	// Written by the googlemanagedprometheus exporter, job and instance are
+	// from service.name and service.instance.id
+	"prometheus_target": {"location", "job", "instance"},
+}
+
+// Values the monitored resource labels must have, known from the terraform
+// outputs. Set by the Setup functions.
+var expectedResourceLabels = map[string]string{}
+
+// checkResource returns what's wrong with the monitored resource the
+// collector's telemetry was written to, or nil if it's as expected.
+func checkResource(
+	wantType string,
+	gotType string,
+	labels map[string]string,
+	expected map[string]string,
+) []string {
+	if gotType != wantType {
+		return []string{fmt.Sprintf("resource type is %q, expected %q", gotType, wantType)}
+	}
+
+	var problems []string
+	missing := map[string]bool{}
+	for _, key := range requiredResourceLabels[wantType] {
+		if labels[key] == "" {
+			missing[key] = true
+			problems = append(problems, fmt.Sprintf("resource label %q is missing", key))
+		}
+	}
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !missing[key] && labels[key] != expected[key] {
+			problems = append(problems, fmt.Sprintf("resource label %q is %q, expected %q", key, labels[key], expected[key]))
+		}
+	}
+	return problems
+}
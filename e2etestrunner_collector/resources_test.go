@@ -0,0 +1,64 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Offline unit tests, excluded from the e2ecollector test binary
+//go:build !e2ecollector
+
+package e2etestrunner_collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResource(t *testing.T) {
+	gkeLabels := map[string]string{
+		"project_id":     "project",
+		"location":       "us-central1",
+		"cluster_name":   "e2e-cluster",
+		"namespace_name": "default",
+		"pod_name":       "collector-abc123",
+		"container_name": "collector",
+	}
+	expected := map[string]string{
+		"cluster_name":   "e2e-cluster",
+		"location":       "us-central1",
+		"namespace_name": "default",
+	}
+
+	assert.Empty(t, checkResource("k8s_container", "k8s_container", gkeLabels, expected))
+
+	assert.Equal(t, []string{`resource type is "k8s_pod", expected "k8s_container"`},
+		checkResource("k8s_container", "k8s_pod", gkeLabels, expected))
+
+	assert.Equal(t, []string{
+		`resource label "location" is missing`,
+		`resource label "container_name" is missing`,
+		`resource label "namespace_name" is "other", expected "default"`,
+	}, checkResource("k8s_container", "k8s_container", map[string]string{
+		"cluster_name":   "e2e-cluster",
+		"namespace_name": "other",
+		"pod_name":       "collector-abc123",
+	}, expected))
+
+	// Without terraform outputs, only the required labels are checked
+	assert.Empty(t, checkResource("generic_task", "generic_task", map[string]string{
+		"location": "us-central1",
+		"job":      "otelcol-google",
+		"task_id":  "00f1",
+	}, nil))
+	assert.Equal(t, []string{`resource label "instance_id" is missing`},
+		checkResource("gce_instance", "gce_instance", map[string]string{"zone": "us-central1-a"}, map[string]string{"zone": "us-central1-a"}))
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Offline unit tests, excluded from the e2ecollector test binary
+//go:build !e2ecollector
+
+package e2etestrunner_collector
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf/fakeprovisioner"
+)
+
+func TestSetupCollectorFuncs(t *testing.T) {
+	image := e2etesting.CmdWithImage{Image: "collector:latest"}
+	cases := []struct {
+		name          string
+		setupFunc     e2etesting.SetupCollectorFunc
+		args          e2etesting.Args
+		expectDir     string
+		expectVariant string
+		expectVars    map[string]string
+	}{
+		{
+			name:      "gce-collector",
+			setupFunc: SetupGceCollector,
+			args:      e2etesting.Args{GceCollector: &e2etesting.GceCollectorCmd{CmdWithImage: image}},
+			expectDir: gceCollectorTfDir,
+		},
+		{
+			name:      "gce-collector with a config variant",
+			setupFunc: SetupGceCollector,
+			args: e2etesting.Args{GceCollector: &e2etesting.GceCollectorCmd{
+				CmdWithImage:           image,
+				CmdWithCollectorConfig: e2etesting.CmdWithCollectorConfig{ConfigVariant: "otlp"},
+			}},
+			expectDir:     gceCollectorTfDir,
+			expectVariant: "otlp",
+		},
+		{
+			name:      "gce-collector-arm",
+			setupFunc: SetupGceCollectorArm,
+			args:      e2etesting.Args{GceCollectorArm: &e2etesting.GceCollectorArmCmd{CmdWithImage: image}},
+			expectDir: gceCollectorArmTfDir,
+		},
+		{
+			name:      "gke-collector",
+			setupFunc: SetupGkeCollector,
+			args:      e2etesting.Args{GkeCollector: &e2etesting.GkeCollectorCmd{CmdWithImage: image}},
+			expectDir: gkeCollectorTfDir,
+		},
+		{
+			name:      "gke-collector with the load generator",
+			setupFunc: SetupGkeCollector,
+			args: e2etesting.Args{GkeCollector: &e2etesting.GkeCollectorCmd{
+				CmdWithImage: image,
+				CmdWithLoadgen: e2etesting.CmdWithLoadgen{
+					LoadgenImage:        "loadgen:latest",
+					LoadgenSpans:        1000,
+					LoadgenMetricSeries: 100,
+					LoadgenLogs:         500,
+				},
+			}},
+			expectDir: gkeCollectorTfDir,
+			expectVars: map[string]string{
+				"loadgen_image":         "loadgen:latest",
+				"loadgen_spans":         "1000",
+				"loadgen_metric_series": "100",
+				"loadgen_logs":          "500",
+			},
+		},
+		{
+			name:      "gke-operator-collector",
+			setupFunc: SetupGkeOperatorCollector,
+			args:      e2etesting.Args{GkeOperatorCollector: &e2etesting.GkeOperatorCollectorCmd{CmdWithImage: image}},
+			expectDir: gkeOperatorCollectorTfDir,
+		},
+		{
+			name:      "cloud-run-collector",
+			setupFunc: SetupCloudRunCollector,
+			args:      e2etesting.Args{CloudRunCollector: &e2etesting.CloudRunCollectorCmd{CmdWithImage: image}},
+			expectDir: cloudRunCollectorTfDir,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The collector configs don't output pubsub_info
+			fake := &fakeprovisioner.Provisioner{}
+			fakeprovisioner.Install(t, fake)
+
+			expectVariant := tc.expectVariant
+			if expectVariant == "" {
+				expectVariant = "default"
+			}
+			expectVars := map[string]string{"image": "collector:latest", "config_variant": expectVariant}
+			for k, v := range tc.expectVars {
+				expectVars[k] = v
+			}
+			tc.args.ProjectID = "project"
+			tc.args.TestRunID = "abc123"
+			cleanup, err := tc.setupFunc(context.Background(), &tc.args, log.New(io.Discard, "", 0))
+			require.NoError(t, err)
+			assert.Equal(t, []setuptf.ApplyRequest{{
+				ProjectID: "project",
+				TestRunID: "abc123",
+				Dir:       tc.expectDir,
+				Vars:      expectVars,
+			}}, fake.Applied())
+
+			cleanup()
+			assert.Len(t, fake.Destroyed(), 1)
+		})
+	}
+}
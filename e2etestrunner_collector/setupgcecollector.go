@@ -24,6 +24,12 @@ import (
 
 const gceCollectorTfDir string = "tf/gce-collector"
 
+// The outputs of tf/gce-collector and tf/gce-collector-arm
+type gceCollectorOutputs struct {
+	InstanceName string `json:"instance_name"`
+	InstanceZone string `json:"instance_zone"`
+}
+
 // SetupGceCollector Set up the collector to run in GCE container. Creates a new
 // GCE VM resources, and runs the specified container image. The
 // returned cleanup function tears down the VM.
@@ -32,16 +38,32 @@ func SetupGceCollector(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (e2etesting.Cleanup, error) {
-	_, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
 		gceCollectorTfDir,
 		map[string]string{
-			"image": args.GceCollector.Image,
+			"image":          args.GceCollector.Image,
+			"config_variant": args.CollectorConfigVariant(),
 		},
 		logger,
 	)
+	if err != nil {
+		return cleanupTf, err
+	}
+	tfOutputs := gceCollectorOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return cleanupTf, err
+	}
+	expectedResourceLabels = map[string]string{"zone": tfOutputs.InstanceZone}
+	logger.Printf(
+		"Collector running in GCE instance %v: https://console.cloud.google.com/compute/instancesDetail/zones/%v/instances/%v?project=%v\n",
+		tfOutputs.InstanceName,
+		tfOutputs.InstanceZone,
+		tfOutputs.InstanceName,
+		args.ProjectID,
+	)
 
-	return cleanupTf, err
+	return cleanupTf, nil
 }
@@ -32,16 +32,32 @@ func SetupGceCollectorArm(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (e2etesting.Cleanup, error) {
-	_, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
 		gceCollectorArmTfDir,
 		map[string]string{
-			"image": args.GceCollectorArm.Image,
+			"image":          args.GceCollectorArm.Image,
+			"config_variant": args.CollectorConfigVariant(),
 		},
 		logger,
 	)
+	if err != nil {
+		return cleanupTf, err
+	}
+	tfOutputs := gceCollectorOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return cleanupTf, err
+	}
+	expectedResourceLabels = map[string]string{"zone": tfOutputs.InstanceZone}
+	logger.Printf(
+		"Collector running in GCE instance %v: https://console.cloud.google.com/compute/instancesDetail/zones/%v/instances/%v?project=%v\n",
+		tfOutputs.InstanceName,
+		tfOutputs.InstanceZone,
+		tfOutputs.InstanceName,
+		args.ProjectID,
+	)
 
-	return cleanupTf, err
+	return cleanupTf, nil
 }
@@ -24,6 +24,12 @@ import (
 
 const cloudRunCollectorTfDir string = "tf/cloud-run-collector"
 
+// The outputs of tf/cloud-run-collector
+type cloudRunCollectorOutputs struct {
+	ServiceName string `json:"service_name"`
+	ServiceURL  string `json:"service_url"`
+}
+
 // SetupCloudRunCollector sets up the collector to run in Cloud Run.
 // Creates a new service and runs the specified container image as a revision.
 // The returned cleanup function tears down everything.
@@ -32,16 +38,25 @@ func SetupCloudRunCollector(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (e2etesting.Cleanup, error) {
-	_, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
 		cloudRunCollectorTfDir,
 		map[string]string{
-			"image": args.CloudRunCollector.Image,
+			"image":          args.CloudRunCollector.Image,
+			"config_variant": args.CollectorConfigVariant(),
 		},
 		logger,
 	)
+	if err != nil {
+		return cleanupTf, err
+	}
+	tfOutputs := cloudRunCollectorOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return cleanupTf, err
+	}
+	logger.Printf("Collector running in Cloud Run service %v at %v\n", tfOutputs.ServiceName, tfOutputs.ServiceURL)
 
-	return cleanupTf, err
+	return cleanupTf, nil
 }
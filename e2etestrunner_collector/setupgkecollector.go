@@ -3,6 +3,7 @@ package e2etestrunner_collector
 import (
 	"context"
 	"log"
+	"strconv"
 
 	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting"
 	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/setuptf"
@@ -10,6 +11,14 @@ import (
 
 const gkeCollectorTfDir string = "tf/gke-collector"
 
+// The outputs of tf/gke-collector
+type gkeCollectorOutputs struct {
+	ClusterName     string `json:"cluster_name"`
+	ClusterLocation string `json:"cluster_location"`
+	PodName         string `json:"pod_name"`
+	Namespace       string `json:"namespace"`
+}
+
 // SetupGkeCollector Set up the collector to run in GKE.
 // Creates a new pod and runs the specified container image in a pod.
 // The returned cleanup function tears down the whole cluster.
@@ -18,16 +27,49 @@ func SetupGkeCollector(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (e2etesting.Cleanup, error) {
-	_, cleanupTf, err := setuptf.SetupTf(
+	tfVars := map[string]string{
+		"image":          args.GkeCollector.Image,
+		"config_variant": args.CollectorConfigVariant(),
+	}
+	if loadgen := args.GkeCollector.CmdWithLoadgen; loadgen.LoadgenImage != "" {
+		// Applying waits for the load generator to finish sending
+		tfVars["loadgen_image"] = loadgen.LoadgenImage
+		tfVars["loadgen_spans"] = strconv.Itoa(loadgen.LoadgenSpans)
+		tfVars["loadgen_metric_series"] = strconv.Itoa(loadgen.LoadgenMetricSeries)
+		tfVars["loadgen_logs"] = strconv.Itoa(loadgen.LoadgenLogs)
+	}
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
 		gkeCollectorTfDir,
-		map[string]string{
-			"image": args.GkeCollector.Image,
-		},
+		tfVars,
 		logger,
 	)
+	if err != nil {
+		return cleanupTf, err
+	}
+	tfOutputs := gkeCollectorOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return cleanupTf, err
+	}
+	expectedResourceLabels = map[string]string{
+		"cluster_name":   tfOutputs.ClusterName,
+		"location":       tfOutputs.ClusterLocation,
+		"namespace_name": tfOutputs.Namespace,
+		"pod_name":       tfOutputs.PodName,
+		"container_name": "collector",
+	}
+	logger.Printf(
+		"Collector running in GKE pod %v/%v: https://console.cloud.google.com/kubernetes/pod/%v/%v/%v/%v/details?project=%v\n",
+		tfOutputs.Namespace,
+		tfOutputs.PodName,
+		tfOutputs.ClusterLocation,
+		tfOutputs.ClusterName,
+		tfOutputs.Namespace,
+		tfOutputs.PodName,
+		args.ProjectID,
+	)
 
-	return cleanupTf, err
+	return cleanupTf, nil
 }
@@ -10,6 +10,13 @@ import (
 
 const gkeOperatorCollectorTfDir string = "tf/gke-operator-collector"
 
+// The outputs of tf/gke-operator-collector
+type gkeOperatorCollectorOutputs struct {
+	ClusterName     string `json:"cluster_name"`
+	ClusterLocation string `json:"cluster_location"`
+	Namespace       string `json:"namespace"`
+}
+
 // SetupGkeOperatorCollector Set up the collector to run in GKE.
 // Creates a new pod and runs the specified container image in a pod.
 // The returned cleanup function tears down the whole cluster.
@@ -18,16 +25,35 @@ func SetupGkeOperatorCollector(
 	args *e2etesting.Args,
 	logger *log.Logger,
 ) (e2etesting.Cleanup, error) {
-	_, cleanupTf, err := setuptf.SetupTf(
+	outputs, cleanupTf, err := setuptf.SetupTfOutputs(
 		ctx,
 		args.ProjectID,
 		args.TestRunID,
 		gkeOperatorCollectorTfDir,
 		map[string]string{
-			"image": args.GkeOperatorCollector.Image,
+			"image":          args.GkeOperatorCollector.Image,
+			"config_variant": args.CollectorConfigVariant(),
 		},
 		logger,
 	)
+	if err != nil {
+		return cleanupTf, err
+	}
+	tfOutputs := gkeOperatorCollectorOutputs{}
+	if err := outputs.DecodeAll(&tfOutputs); err != nil {
+		return cleanupTf, err
+	}
+	expectedResourceLabels = map[string]string{
+		"cluster_name":   tfOutputs.ClusterName,
+		"location":       tfOutputs.ClusterLocation,
+		"namespace_name": tfOutputs.Namespace,
+	}
+	logger.Printf(
+		"Collector deployed by the operator to namespace %v in GKE cluster %v/%v\n",
+		tfOutputs.Namespace,
+		tfOutputs.ClusterLocation,
+		tfOutputs.ClusterName,
+	)
 
-	return cleanupTf, err
+	return cleanupTf, nil
 }
@@ -4,24 +4,15 @@ package e2etestrunner_collector
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"strings"
 	"testing"
-	"time"
 
 	"cloud.google.com/go/logging"
 	"cloud.google.com/go/logging/logadmin"
 	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
 	monitoringpb "cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
 	"google.golang.org/api/cloudtrace/v1"
-	"google.golang.org/api/iterator"
-	"google.golang.org/protobuf/types/known/timestamppb"
-)
-
-const (
-	representativeMetric = "workload.googleapis.com/otelcol_exporter_sent_metric_points"
-	queryMaxAttempts     = 3
+	"google.golang.org/genproto/googleapis/api/monitoredres"
 )
 
 func TestMetrics(t *testing.T) {
@@ -32,54 +23,24 @@ func TestMetrics(t *testing.T) {
 	}
 	defer c.Close()
 
-	tsList := make([]*monitoringpb.TimeSeries, 0)
-	for attempt := 1; attempt <= queryMaxAttempts; attempt++ {
-		window := 10 * time.Minute
-		now := time.Now()
-		start := timestamppb.New(now.Add(-window))
-		end := timestamppb.New(now)
-		fmt.Println(fmt.Sprintf("resource.type = %q", resourceType))
-		filters := []string{
-			fmt.Sprintf("metric.type = %q", representativeMetric),
-			fmt.Sprintf("resource.type = %q", resourceType),
-			fmt.Sprintf("metric.labels.%s = %s", labelName, args.TestRunID),
-		}
-
-		req := &monitoringpb.ListTimeSeriesRequest{
-			Name:   "projects/" + args.ProjectID,
-			Filter: strings.Join(filters, " AND "),
-			Interval: &monitoringpb.TimeInterval{
-				EndTime:   end,
-				StartTime: start,
-			},
-			View: monitoringpb.ListTimeSeriesRequest_FULL,
-		}
-
-		tsIter := c.ListTimeSeries(ctx, req)
-		for {
-			series, err := tsIter.Next()
-			if errors.Is(err, iterator.Done) {
-				break
-			}
-			if err != nil {
-				t.Fatal("Timeseries query failed with error:", err)
-			}
-			if len(series.Points) == 0 {
-				continue
-			}
-			tsList = append(tsList, series)
-		}
-		if len(tsList) == 0 {
-			continue
-		}
-		break
-	}
-
-	if len(tsList) == 0 {
-		t.Fatal(fmt.Errorf("Could not find representative metric: %q", representativeMetric))
+	metricResourceType := variant.metricResourceType(resourceType)
+	filter := metricFilter(variant.RepresentativeMetric, metricResourceType, args.TestRunID)
+	tsList, err := retryQuery(ctx, "ListTimeSeries", args.MetricBackoffInitial, args.MetricBackoffTotal, t.Logf,
+		func(ctx context.Context) ([]*monitoringpb.TimeSeries, error) {
+			return listTimeSeries(ctx, c, args.ProjectID, filter, monitoringpb.ListTimeSeriesRequest_FULL, runStart, maxQueryResults)
+		},
+	)
+	if err != nil {
+		t.Fatalf("Could not find representative metric %q matching filter %s: %v", variant.RepresentativeMetric, filter, err)
 	}
 
 	fmt.Printf("Found representative metric: %v\n", tsList[0].Metric)
+	// The terraform outputs are only known for the platform's resource type
+	expected := expectedResourceLabels
+	if metricResourceType != resourceType {
+		expected = nil
+	}
+	checkMonitoredResource(t, "Metric", metricResourceType, expected, tsList[0].Resource)
 }
 
 func TestLogging(t *testing.T) {
@@ -88,29 +49,23 @@ func TestLogging(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	start := time.Now().Add(-time.Hour)
-
-	window := start.Format(time.RFC3339)
-	filter := fmt.Sprintf(`resource.type="%s" AND labels.%s="%s" AND timestamp > "%s"`, resourceType, labelName, args.TestRunID, window)
-
-	var entry *logging.Entry
-	for attempt := 1; attempt <= queryMaxAttempts; attempt++ {
-		entries := client.Entries(ctx,
-			logadmin.Filter(filter),
-		)
-
-		entry, err = entries.Next()
-		if err == nil {
-			// log found
-			break
-		}
-		time.Sleep(10 * time.Second)
-	}
-
+	defer client.Close()
+
+	filter := logFilter(resourceType, args.TestRunID)
+	entries, err := retryQuery(ctx, "ListLogEntries", args.LogBackoffInitial, args.LogBackoffTotal, t.Logf,
+		func(ctx context.Context) ([]*logging.Entry, error) {
+			return listLogEntries(ctx, client, filter, runStart, 1)
+		},
+	)
 	if err != nil {
-		t.Fatalf(fmt.Sprintf("Could not find any logs matching filter %s: %v", filter, err))
+		t.Fatalf("Could not find any logs matching filter %s: %v", filter, err)
 	}
+	entry := entries[0]
 	fmt.Println(fmt.Sprintf("Entry found: %v", entry))
+	checkMonitoredResource(t, "Log entry", resourceType, expectedResourceLabels, entry.Resource)
+	if want := fmt.Sprintf("projects/%s/logs/%s", args.ProjectID, variant.LogName); entry.LogName != want {
+		t.Errorf("Log entry has log name %q, expected the googlecloud exporter's default_log_name %q", entry.LogName, want)
+	}
 }
 
 func TestTraces(t *testing.T) {
@@ -120,15 +75,74 @@ func TestTraces(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	req := cloudService.Projects.Traces.List(args.ProjectID)
-	req.Filter(resourceFilter)
-	resp, err := req.Do()
+	filter := traceFilter(args.TestRunID)
+	traces, err := retryQuery(ctx, "ListTraces", args.TraceBackoffInitial, args.TraceBackoffTotal, t.Logf,
+		func(ctx context.Context) ([]*cloudtrace.Trace, error) {
+			return listTraces(ctx, cloudService, args.ProjectID, filter, "COMPLETE", runStart, maxQueryResults)
+		},
+	)
+	if err != nil {
+		t.Fatalf("Could not find traces with resource attribute %s: %v", filter, err)
+	}
+	fmt.Println(fmt.Sprintf("Found %d traces with resource attribute %s", len(traces), filter))
+
+	// The filter matches label values by prefix, check the transform processor
+	// set exactly the test run ID
+	for _, trace := range traces {
+		for _, span := range trace.Spans {
+			if span.Labels[labelName] == args.TestRunID {
+				checkTraceExporter(t, span)
+				return
+			}
+		}
+	}
+	t.Errorf("Found no spans with the %s=%s attribute set by the transform processor", labelName, args.TestRunID)
+}
+
+// Fails the test if the span wasn't exported the way the config variant
+// exports traces.
+func checkTraceExporter(t *testing.T, span *cloudtrace.TraceSpan) {
+	_, hasAgent := span.Labels[agentLabel]
+	switch {
+	case variant.GooglecloudTraces && !hasAgent:
+		t.Errorf("Span %v is missing the %v label the googlecloud exporter adds", span.Name, agentLabel)
+	case !variant.GooglecloudTraces && hasAgent:
+		t.Errorf("Span %v has the %v label %q, but should not have been exported with the googlecloud exporter", span.Name, agentLabel, span.Labels[agentLabel])
+	}
+}
+
+func TestVariantProcessorMetric(t *testing.T) {
+	if variant.ProcessorMetric == "" {
+		t.Skipf("The %v config variant has no processor metric", args.CollectorConfigVariant())
+	}
+	ctx := context.Background()
+	c, err := monitoring.NewMetricClient(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
-	tracesFound := len(resp.Traces)
-	if tracesFound == 0 {
-		t.Errorf(fmt.Sprintf("Could not find traces with resource attribute: %s", resourceFilter))
+	defer c.Close()
+
+	filter := metricFilter(variant.ProcessorMetric, variant.metricResourceType(resourceType), args.TestRunID)
+	_, err = retryQuery(ctx, "ListTimeSeries", args.MetricBackoffInitial, args.MetricBackoffTotal, t.Logf,
+		func(ctx context.Context) ([]*monitoringpb.TimeSeries, error) {
+			return listTimeSeries(ctx, c, args.ProjectID, filter, monitoringpb.ListTimeSeriesRequest_HEADERS, runStart, 1)
+		},
+	)
+	if err != nil {
+		t.Fatalf("Could not find processor metric %q matching filter %s: %v", variant.ProcessorMetric, filter, err)
+	}
+}
+
+// Fails the test if the monitored resource the telemetry was written to doesn't
+// have what the resourcedetection processor should have detected.
+func checkMonitoredResource(
+	t *testing.T,
+	signal string,
+	wantType string,
+	expected map[string]string,
+	resource *monitoredres.MonitoredResource,
+) {
+	for _, problem := range checkResource(wantType, resource.GetType(), resource.GetLabels(), expected) {
+		t.Errorf("%s %s", signal, problem)
 	}
-	fmt.Println(fmt.Sprintf("Found traces with resource attribute%s", resourceFilter))
 }
@@ -0,0 +1,109 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2etestrunner_collector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/opentelemetry-operations-e2e-testing/e2etesting/loadgen"
+)
+
+// configVariant is what the smoke tests expect of the telemetry exported with
+// one of the collector configs in tf/modules/otel-config.
+type configVariant struct {
+	// The metric the collector's own metrics are written as, which the
+	// readiness check and TestMetrics look for
+	RepresentativeMetric string
+	// The monitored resource type of the metrics, if it isn't the platform's,
+	// e.g. prometheus_target for Google Managed Prometheus
+	MetricResourceType string
+	// The log name the collector's logs are written to
+	LogName string
+	// The metric the load generator's loadgen.series is written as
+	LoadgenMetric string
+	// A metric only the variant's extra processors write, if any, which
+	// TestVariantProcessorMetric looks for
+	ProcessorMetric string
+	// Whether traces are exported with the googlecloud exporter, which adds
+	// the g.co/agent label to every span. Spans exported to
+	// telemetry.googleapis.com with OTLP don't have it.
+	GooglecloudTraces bool
+}
+
+var configVariants = map[string]configVariant{
+	"default": {
+		RepresentativeMetric: representativeMetric,
+		LogName:              defaultLogName,
+		LoadgenMetric:        "workload.googleapis.com/" + loadgen.MetricName,
+		GooglecloudTraces:    true,
+	},
+	// memory_limiter first and batch last, as recommended for production. The
+	// batch processor reports the size of the batches it sends.
+	"batch-memorylimiter": {
+		RepresentativeMetric: representativeMetric,
+		LogName:              defaultLogName,
+		LoadgenMetric:        "workload.googleapis.com/" + loadgen.MetricName,
+		ProcessorMetric:      "workload.googleapis.com/otelcol_processor_batch_batch_send_size",
+		GooglecloudTraces:    true,
+	},
+	// Metrics exported with the googlemanagedprometheus exporter, which adds
+	// the _total suffix to counters
+	"googlemanagedprometheus": {
+		RepresentativeMetric: "prometheus.googleapis.com/otelcol_exporter_sent_metric_points_total/counter",
+		MetricResourceType:   "prometheus_target",
+		LogName:              defaultLogName,
+		LoadgenMetric:        "prometheus.googleapis.com/loadgen_series_total/counter",
+		GooglecloudTraces:    true,
+	},
+	// Traces exported with the otlphttp exporter to telemetry.googleapis.com
+	"otlp": {
+		RepresentativeMetric: representativeMetric,
+		LogName:              defaultLogName,
+		LoadgenMetric:        "workload.googleapis.com/" + loadgen.MetricName,
+	},
+}
+
+// lookupConfigVariant returns the expectations of the --config-variant.
+func lookupConfigVariant(name string) (configVariant, error) {
+	variant, ok := configVariants[name]
+	if !ok {
+		return configVariant{}, fmt.Errorf(
+			"unknown collector config variant %q, expected one of %v",
+			name,
+			strings.Join(sortedVariantNames(), ", "),
+		)
+	}
+	return variant, nil
+}
+
+func sortedVariantNames() []string {
+	names := make([]string, 0, len(configVariants))
+	for name := range configVariants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// metricResourceType returns the monitored resource type the metrics are
+// written to when the collector runs on the platform's resource type.
+func (v configVariant) metricResourceType(platformResourceType string) string {
+	if v.MetricResourceType != "" {
+		return v.MetricResourceType
+	}
+	return platformResourceType
+}
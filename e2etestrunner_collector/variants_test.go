@@ -0,0 +1,77 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Offline unit tests, excluded from the e2ecollector test binary
+//go:build !e2ecollector
+
+package e2etestrunner_collector
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupConfigVariant(t *testing.T) {
+	variant, err := lookupConfigVariant("default")
+	require.NoError(t, err)
+	assert.Equal(t, "k8s_container", variant.metricResourceType("k8s_container"))
+
+	variant, err = lookupConfigVariant("googlemanagedprometheus")
+	require.NoError(t, err)
+	assert.Equal(t, "prometheus_target", variant.metricResourceType("k8s_container"))
+	assert.Contains(t, requiredResourceLabels, variant.MetricResourceType)
+
+	// Both metrics go through the same exporter
+	for name, variant := range configVariants {
+		prefix := "workload.googleapis.com/"
+		if variant.MetricResourceType == "prometheus_target" {
+			prefix = "prometheus.googleapis.com/"
+		}
+		assert.Truef(t, strings.HasPrefix(variant.RepresentativeMetric, prefix), "%v RepresentativeMetric", name)
+		assert.Truef(t, strings.HasPrefix(variant.LoadgenMetric, prefix), "%v LoadgenMetric", name)
+	}
+
+	_, err = lookupConfigVariant("nope")
+	assert.ErrorContains(t, err, "batch-memorylimiter, default, googlemanagedprometheus, otlp")
+}
+
+// Each variant must expect something the others don't, otherwise the smoke
+// tests can't tell whether the variant changed anything
+func TestConfigVariantsDistinct(t *testing.T) {
+	names := sortedVariantNames()
+	for i, name := range names {
+		for _, other := range names[i+1:] {
+			assert.NotEqualf(t, configVariants[name], configVariants[other], "%v and %v have the same expectations", name, other)
+		}
+	}
+}
+
+// The variants the terraform module accepts must all have expectations
+func TestConfigVariantsMatchTerraform(t *testing.T) {
+	config, err := os.ReadFile("../tf/modules/otel-config/main.tf")
+	require.NoError(t, err)
+	match := regexp.MustCompile(`condition\s*=\s*contains\(\[([^\]]*)\], var\.variant\)`).FindSubmatch(config)
+	require.NotNil(t, match, "couldn't find the variant validation in tf/modules/otel-config")
+
+	var tfVariants []string
+	for _, quoted := range regexp.MustCompile(`"([^"]+)"`).FindAllSubmatch(match[1], -1) {
+		tfVariants = append(tfVariants, string(quoted[1]))
+	}
+	assert.ElementsMatch(t, tfVariants, sortedVariantNames())
+}
@@ -87,3 +87,13 @@ output "pubsub_info" {
   value       = module.pubsub.info
   description = "Info about the request/response pubsub topics and subscription to use in the test"
 }
+
+output "function_name" {
+  value       = google_cloudfunctions2_function.function.name
+  description = "Name of the Cloud Function running the test server"
+}
+
+output "function_url" {
+  value       = google_cloudfunctions2_function.function.service_config[0].uri
+  description = "URL of the Cloud Function running the test server"
+}
@@ -58,8 +58,24 @@ resource "google_cloud_run_service" "default" {
 module "otel_config" {
   source      = "../modules/otel-config"
   test_run_id = terraform.workspace
+  variant     = var.config_variant
 }
 
 variable "image" {
   type = string
 }
+
+variable "config_variant" {
+  type    = string
+  default = "default"
+}
+
+output "service_name" {
+  value       = google_cloud_run_service.default.name
+  description = "Name of the Cloud Run service running the collector"
+}
+
+output "service_url" {
+  value       = google_cloud_run_service.default.status[0].url
+  description = "URL of the Cloud Run service running the collector"
+}
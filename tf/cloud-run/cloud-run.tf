@@ -100,3 +100,13 @@ output "pubsub_info" {
   value       = module.pubsub.info
   description = "Info about the request/response pubsub topics and subscription to use in the test"
 }
+
+output "service_name" {
+  value       = google_cloud_run_service.default.name
+  description = "Name of the Cloud Run service running the test server"
+}
+
+output "service_url" {
+  value       = google_cloud_run_service.default.status[0].url
+  description = "URL of the Cloud Run service running the test server"
+}
@@ -25,6 +25,10 @@ terraform {
       source  = "hashicorp/kubernetes"
       version = "2.35.1"
     }
+    time = {
+      source  = "hashicorp/time"
+      version = "0.13.1"
+    }
   }
 
   backend "gcs" {
@@ -55,3 +59,9 @@ locals {
   gke_cluster_name     = "e2etest-default"
   gke_cluster_location = "us-central1"
 }
+
+# The janitor destroys the workspaces of killed test runs with the directory
+# recorded here
+output "config_dir" {
+  value = basename(abspath(path.root))
+}
@@ -86,3 +86,13 @@ output "pubsub_info" {
   value       = module.pubsub.info
   description = "Info about the request/response pubsub topics and subscription to use in the test"
 }
+
+output "service_name" {
+  value       = google_app_engine_flexible_app_version.test_service.service
+  description = "Name of the App Engine service running the test server"
+}
+
+output "version_id" {
+  value       = google_app_engine_flexible_app_version.test_service.version_id
+  description = "Version of the App Engine service running the test server"
+}
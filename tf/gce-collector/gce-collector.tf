@@ -69,8 +69,24 @@ module "gce_container" {
 module "otel_config" {
   source      = "../modules/otel-config"
   test_run_id = terraform.workspace
+  variant     = var.config_variant
 }
 
 variable "image" {
   type = string
 }
+
+variable "config_variant" {
+  type    = string
+  default = "default"
+}
+
+output "instance_name" {
+  value       = google_compute_instance.default.name
+  description = "Name of the GCE instance running the collector"
+}
+
+output "instance_zone" {
+  value       = google_compute_instance.default.zone
+  description = "Zone of the GCE instance running the collector"
+}
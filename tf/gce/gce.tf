@@ -92,3 +92,13 @@ output "pubsub_info" {
   value       = module.pubsub.info
   description = "Info about the request/response pubsub topics and subscription to use in the test"
 }
+
+output "instance_name" {
+  value       = google_compute_instance.default.name
+  description = "Name of the GCE instance running the test server"
+}
+
+output "instance_zone" {
+  value       = google_compute_instance.default.zone
+  description = "Zone of the GCE instance running the test server"
+}
@@ -28,6 +28,9 @@ provider "kubernetes" {
 resource "kubernetes_pod" "collector" {
   metadata {
     name = "collector-${terraform.workspace}"
+    labels = {
+      app = "collector-${terraform.workspace}"
+    }
   }
 
   spec {
@@ -82,9 +85,126 @@ resource "kubernetes_pod" "collector" {
 module "otel_config" {
   source      = "../modules/otel-config"
   test_run_id = terraform.workspace
+  variant     = var.config_variant
+  loadgen     = var.loadgen_image != ""
+}
+
+# The load generator sends telemetry to the collector's otlp receiver through
+# this service, and the job completes once it's all sent
+resource "kubernetes_service" "collector" {
+  count = var.loadgen_image != "" ? 1 : 0
+
+  metadata {
+    name = "collector-${terraform.workspace}"
+  }
+
+  spec {
+    selector = kubernetes_pod.collector.metadata[0].labels
+    port {
+      name        = "otlp-grpc"
+      port        = 4317
+      target_port = 4317
+    }
+  }
+}
+
+resource "kubernetes_job" "loadgen" {
+  count = var.loadgen_image != "" ? 1 : 0
+
+  metadata {
+    name = "loadgen-${terraform.workspace}"
+  }
+
+  spec {
+    # A retry would send the batches exported before the failure again, so fail
+    # the apply instead of reporting duplicates
+    backoff_limit = 0
+    template {
+      metadata {}
+      spec {
+        restart_policy = "Never"
+        container {
+          image = var.loadgen_image
+          name  = "loadgen"
+
+          env {
+            name  = "OTLP_ENDPOINT"
+            value = "${kubernetes_service.collector[0].metadata[0].name}:4317"
+          }
+          env {
+            name  = "TEST_RUN_ID"
+            value = terraform.workspace
+          }
+          env {
+            name  = "LOADGEN_SPANS"
+            value = tostring(var.loadgen_spans)
+          }
+          env {
+            name  = "LOADGEN_METRIC_SERIES"
+            value = tostring(var.loadgen_metric_series)
+          }
+          env {
+            name  = "LOADGEN_LOGS"
+            value = tostring(var.loadgen_logs)
+          }
+        }
+      }
+    }
+  }
+
+  wait_for_completion = true
+  timeouts {
+    create = "10m"
+  }
 }
 
 
 variable "image" {
   type = string
 }
+
+variable "config_variant" {
+  type    = string
+  default = "default"
+}
+
+variable "loadgen_image" {
+  type        = string
+  default     = ""
+  description = "cmd/loadgen image to run next to the collector, or empty to not run it"
+}
+
+variable "loadgen_spans" {
+  type    = number
+  default = 0
+}
+
+variable "loadgen_metric_series" {
+  type    = number
+  default = 0
+}
+
+variable "loadgen_logs" {
+  type    = number
+  default = 0
+}
+
+output "cluster_name" {
+  value       = data.google_container_cluster.default.name
+  description = "Name of the GKE cluster the collector runs in"
+}
+
+output "cluster_location" {
+  value       = data.google_container_cluster.default.location
+  description = "Location of the GKE cluster the collector runs in"
+}
+
+output "pod_name" {
+  value       = kubernetes_pod.collector.metadata[0].name
+  description = "Name of the collector pod"
+}
+
+output "namespace" {
+  value       = kubernetes_pod.collector.metadata[0].namespace
+  description = "Namespace of the collector pod"
+}
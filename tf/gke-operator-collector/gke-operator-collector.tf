@@ -88,8 +88,29 @@ resource "kubernetes_manifest" "collector" {
 module "otel_config" {
   source      = "../modules/otel-config"
   test_run_id = terraform.workspace
+  variant     = var.config_variant
 }
 
 variable "image" {
   type = string
 }
+
+variable "config_variant" {
+  type    = string
+  default = "default"
+}
+
+output "cluster_name" {
+  value       = data.google_container_cluster.default.name
+  description = "Name of the GKE cluster the collector runs in"
+}
+
+output "cluster_location" {
+  value       = data.google_container_cluster.default.location
+  description = "Location of the GKE cluster the collector runs in"
+}
+
+output "namespace" {
+  value       = kubernetes_namespace_v1.namespace.metadata[0].name
+  description = "Namespace the operator deploys the collector to"
+}
@@ -93,3 +93,23 @@ output "pubsub_info" {
   value       = module.pubsub.info
   description = "Info about the request/response pubsub topics and subscription to use in the test"
 }
+
+output "cluster_name" {
+  value       = data.google_container_cluster.default.name
+  description = "Name of the GKE cluster the test server runs in"
+}
+
+output "cluster_location" {
+  value       = data.google_container_cluster.default.location
+  description = "Location of the GKE cluster the test server runs in"
+}
+
+output "pod_name" {
+  value       = kubernetes_pod.testserver.metadata[0].name
+  description = "Name of the test server pod"
+}
+
+output "namespace" {
+  value       = kubernetes_pod.testserver.metadata[0].namespace
+  description = "Namespace of the test server pod"
+}
@@ -1,80 +1,172 @@
 variable "test_run_id" {
   type = string
 }
-output "config" {
-  value = {
-    receivers = {
-      "otlp/internal" = {
-        protocols = {
-          grpc = {
-            endpoint = "localhost:14317"
-          }
-          http = {
-            endpoint = "localhost:14318"
-          }
+
+variable "variant" {
+  type        = string
+  default     = "default"
+  description = "Which collector config to test, see the collector config variants section of README.md"
+
+  validation {
+    condition     = contains(["default", "batch-memorylimiter", "googlemanagedprometheus", "otlp"], var.variant)
+    error_message = "The variant must be one of default, batch-memorylimiter, googlemanagedprometheus or otlp."
+  }
+}
+
+variable "loadgen" {
+  type        = bool
+  default     = false
+  description = "Whether to receive the load generator's telemetry on the otlp receiver"
+}
+
+locals {
+  receivers  = concat(["otlp/internal"], var.loadgen ? ["otlp"] : [])
+  processors = ["resourcedetection", "transform"]
+  # The processors and exporters of each signal's pipeline in each variant. The
+  # components are configured below, and only the ones the variant uses are
+  # declared, since the collector fails to load a config with a component type
+  # it wasn't built with.
+  pipelines = {
+    default = {
+      metrics = { processors = local.processors, exporters = ["googlecloud"] }
+      traces  = { processors = local.processors, exporters = ["googlecloud"] }
+      logs    = { processors = local.processors, exporters = ["googlecloud"] }
+    }
+    batch-memorylimiter = {
+      metrics = { processors = concat(["memory_limiter"], local.processors, ["batch"]), exporters = ["googlecloud"] }
+      traces  = { processors = concat(["memory_limiter"], local.processors, ["batch"]), exporters = ["googlecloud"] }
+      logs    = { processors = concat(["memory_limiter"], local.processors, ["batch"]), exporters = ["googlecloud"] }
+    }
+    googlemanagedprometheus = {
+      metrics = { processors = local.processors, exporters = ["googlemanagedprometheus"] }
+      traces  = { processors = local.processors, exporters = ["googlecloud"] }
+      logs    = { processors = local.processors, exporters = ["googlecloud"] }
+    }
+    otlp = {
+      metrics = { processors = local.processors, exporters = ["googlecloud"] }
+      traces  = { processors = concat(local.processors, ["transform/otlp"]), exporters = ["otlphttp"] }
+      logs    = { processors = local.processors, exporters = ["googlecloud"] }
+    }
+  }
+  used_processors = distinct(flatten([for pipeline in values(local.pipelines[var.variant]) : pipeline.processors]))
+  used_exporters  = distinct(flatten([for pipeline in values(local.pipelines[var.variant]) : pipeline.exporters]))
+  # Authenticates the otlphttp exporter
+  extensions = contains(local.used_exporters, "otlphttp") ? ["googleclientauth"] : []
+
+  all_receivers = {
+    otlp = {
+      protocols = {
+        grpc = {
+          endpoint = "0.0.0.0:4317"
         }
       }
     }
-    processors = {
-      resourcedetection = {
-        detectors = ["gcp"]
-      }
-      transform = {
-        error_mode = "ignore"
-        metric_statements = [
-          {
-            context = "datapoint"
-            statements = [
-              "set(attributes[\"otelcol_google_e2e\"], ${format("%q", var.test_run_id)})"
-            ]
-          }
-        ]
-        log_statements = [
-          {
-            context = "log"
-            statements = [
-              "set(attributes[\"otelcol_google_e2e\"], ${format("%q", var.test_run_id)})"
-            ]
-          }
-        ]
-        trace_statements = [
-          {
-            context = "spanevent"
-            statements = [
-              "set(resource.attributes[\"otelcol_google_e2e\"], ${format("%q", var.test_run_id)})"
-            ]
-          }
-        ]
+    "otlp/internal" = {
+      protocols = {
+        grpc = {
+          endpoint = "localhost:14317"
+        }
+        http = {
+          endpoint = "localhost:14318"
+        }
       }
     }
-    exporters = {
-      googlecloud = {
-        log = {
-          default_log_name = "google-otelcol/smoke-test"
+  }
+  all_processors = {
+    resourcedetection = {
+      detectors = ["gcp"]
+    }
+    transform = {
+      error_mode = "ignore"
+      metric_statements = [
+        {
+          context = "datapoint"
+          statements = [
+            "set(attributes[\"otelcol_google_e2e\"], ${format("%q", var.test_run_id)})"
+          ]
+        }
+      ]
+      log_statements = [
+        {
+          context = "log"
+          statements = [
+            "set(attributes[\"otelcol_google_e2e\"], ${format("%q", var.test_run_id)})"
+          ]
+        }
+      ]
+      trace_statements = [
+        {
+          context = "spanevent"
+          statements = [
+            "set(resource.attributes[\"otelcol_google_e2e\"], ${format("%q", var.test_run_id)})"
+          ]
         }
+      ]
+    }
+    # telemetry.googleapis.com needs the project in a resource attribute
+    "transform/otlp" = {
+      error_mode = "ignore"
+      trace_statements = [
+        {
+          context = "resource"
+          statements = [
+            "set(attributes[\"gcp.project_id\"], attributes[\"cloud.account.id\"])"
+          ]
+        }
+      ]
+    }
+    memory_limiter = {
+      check_interval         = "1s"
+      limit_percentage       = 80
+      spike_limit_percentage = 20
+    }
+    batch = {}
+  }
+  all_exporters = {
+    googlecloud = {
+      log = {
+        default_log_name = "google-otelcol/smoke-test"
       }
     }
-
-    extensions = {
-      "health_check" = {}
+    googlemanagedprometheus = {}
+    otlphttp = {
+      encoding = "proto"
+      endpoint = "https://telemetry.googleapis.com"
+      auth = {
+        authenticator = "googleclientauth"
+      }
     }
+  }
+  all_extensions = {
+    "health_check"     = {}
+    "googleclientauth" = {}
+  }
+}
+
+output "config" {
+  value = {
+    receivers  = { for name in local.receivers : name => local.all_receivers[name] }
+    processors = { for name in local.used_processors : name => local.all_processors[name] }
+    exporters  = { for name in local.used_exporters : name => local.all_exporters[name] }
+    extensions = { for name in concat(["health_check"], local.extensions) : name => local.all_extensions[name] }
 
     service = {
+      extensions = local.extensions
       pipelines = {
         metrics = {
-          receivers = ["otlp/internal"]
-          processors = ["resourcedetection", "transform"]
-          exporters = ["googlecloud"]
+          receivers  = local.receivers
+          processors = local.pipelines[var.variant].metrics.processors
+          exporters  = local.pipelines[var.variant].metrics.exporters
         }
         traces = {
-          receivers = ["otlp/internal"]
-          processors = ["resourcedetection", "transform"]
-          exporters = ["googlecloud"]
+          receivers  = local.receivers
+          processors = local.pipelines[var.variant].traces.processors
+          exporters  = local.pipelines[var.variant].traces.exporters
         }
         logs = {
-          receivers = ["otlp/internal"]
-          processors = ["resourcedetection", "transform"]
-          exporters = ["googlecloud"]
+          receivers  = local.receivers
+          processors = local.pipelines[var.variant].logs.processors
+          exporters  = local.pipelines[var.variant].logs.exporters
         }
       }
       telemetry = {
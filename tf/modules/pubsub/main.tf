@@ -13,28 +13,33 @@
 # limitations under the License.
 
 
-// Resources for requests from test runner -> instrumented test server
-resource "google_pubsub_topic" "request" {
-  name = "request-${terraform.workspace}"
+// When the resources were created, so the janitor leaves the topics of a test
+// run alone while its workspace state is still being written
+resource "time_static" "created" {}
 
+locals {
   labels = merge({
     tf-workspace = terraform.workspace
+    created-at   = formatdate("YYYYMMDDhhmmss", time_static.created.rfc3339)
     },
     var.labels
   )
 }
 
+// Resources for requests from test runner -> instrumented test server
+resource "google_pubsub_topic" "request" {
+  name = "request-${terraform.workspace}"
+
+  labels = local.labels
+}
+
 resource "google_pubsub_subscription" "request_subscription" {
   name  = "${google_pubsub_topic.request.name}-pull"
   topic = google_pubsub_topic.request.name
 
   ack_deadline_seconds = 60
 
-  labels = merge({
-    tf-workspace = terraform.workspace
-    },
-    var.labels
-  )
+  labels = local.labels
 
   message_retention_duration = "1200s"
 
@@ -52,11 +57,7 @@ resource "google_pubsub_subscription" "request_subscription" {
 resource "google_pubsub_topic" "response" {
   name = "response-${terraform.workspace}"
 
-  labels = merge({
-    tf-workspace = terraform.workspace
-    },
-    var.labels
-  )
+  labels = local.labels
 }
 
 resource "google_pubsub_subscription" "response_subscription" {
@@ -65,11 +66,7 @@ resource "google_pubsub_subscription" "response_subscription" {
 
   ack_deadline_seconds = 60
 
-  labels = merge({
-    tf-workspace = terraform.workspace
-    },
-    var.labels
-  )
+  labels = local.labels
 
   message_retention_duration = "1200s"
 }